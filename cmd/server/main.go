@@ -18,19 +18,33 @@ import (
 )
 
 func main() {
-	secret := os.Getenv("ATLAS_API_KEY")
-	if secret == "" {
-		log.Fatal("ATLAS_API_KEY must be set")
+	keys := config.AtlasAPIKeys()
+	if dir := config.AtlasReplayDir(); dir != "" {
+		log.Printf("Replaying Atlas responses from %s", dir)
+		if len(keys) == 0 {
+			keys = []string{"replay"}
+		}
+	}
+	if len(keys) == 0 {
+		log.Fatal("ATLAS_API_KEY or ATLAS_API_KEYS must be set")
 	}
+	log.Printf("Using %d Atlas API key(s)", len(keys))
 
-	client := atlas.NewClient(secret)
+	client := atlas.NewPooledClient(keys)
 	liveSvc := live.NewService(client, config.LiveCacheTTL())
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	go liveSvc.RunRefresher(refreshCtx, nil)
 	h := handlers.New(client, liveSvc)
 
 	apiMux := http.NewServeMux()
 	apiMux.HandleFunc("GET /series/live", h.SeriesLive)
 	apiMux.HandleFunc("GET /players/live", h.PlayersLive)
 	apiMux.HandleFunc("GET /teams/live", h.TeamsLive)
+	apiMux.HandleFunc("GET /tournaments/live", h.TournamentsLive)
+	apiMux.HandleFunc("GET /series/live/{id}/matches", h.SeriesLiveMatches)
+	apiMux.HandleFunc("GET /events/live", h.EventsLive)
+	apiMux.HandleFunc("GET /ws", h.WebSocket)
 
 	limiter := middleware.NewLimiter(config.InboundRateLimitRequests(), config.InboundRateLimitPer())
 	mainMux := http.NewServeMux()
@@ -57,6 +71,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Printf("Shutting down...")
+	stopRefresh()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
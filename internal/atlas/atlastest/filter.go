@@ -0,0 +1,220 @@
+package atlastest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Condition is one parsed filter condition, e.g. id<={1,2}.
+type Condition struct {
+	Field  string
+	Op     string   // "=", "!=", "<", "<=", ">", ">=" or "in"
+	Values []string // unescaped; one value except for "in"
+}
+
+// ParseFilter parses an Atlas filter expression as built by atlas.Query:
+// comma-joined conditions of field, operator and value, with set membership
+// written field<={a,b} and \ , { } backslash-escaped inside values.
+func ParseFilter(filter string) ([]Condition, error) {
+	var conds []Condition
+	for _, raw := range splitTop(filter) {
+		c, err := parseCondition(raw)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
+
+// splitTop splits on commas that are neither escaped nor inside braces.
+func splitTop(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func parseCondition(raw string) (Condition, error) {
+	i := 0
+	for i < len(raw) && (raw[i] >= 'a' && raw[i] <= 'z' || raw[i] >= '0' && raw[i] <= '9' || raw[i] == '_' || raw[i] == '.') {
+		i++
+	}
+	field, rest := raw[:i], raw[i:]
+	if field == "" {
+		return Condition{}, fmt.Errorf("invalid filter condition %q: missing field", raw)
+	}
+	if strings.HasPrefix(rest, "<={") {
+		if !strings.HasSuffix(rest, "}") {
+			return Condition{}, fmt.Errorf("invalid filter condition %q: unterminated set", raw)
+		}
+		inner := rest[3 : len(rest)-1]
+		var values []string
+		if inner != "" {
+			for _, v := range splitTop(inner) {
+				values = append(values, unescape(v))
+			}
+		}
+		return Condition{Field: field, Op: "in", Values: values}, nil
+	}
+	for _, op := range []string{"!=", "<=", ">=", "<", ">", "="} {
+		if strings.HasPrefix(rest, op) {
+			return Condition{Field: field, Op: op, Values: []string{unescape(rest[len(op):])}}, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("invalid filter condition %q: unknown operator", raw)
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func filterItems(items []map[string]any, conds []Condition) []map[string]any {
+	out := items[:0:0]
+	for _, item := range items {
+		if matchesAll(item, conds) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func matchesAll(item map[string]any, conds []Condition) bool {
+	for _, c := range conds {
+		if !matches(item, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether any value at c.Field satisfies c. Arrays along the
+// path are flattened, so participants.roster.id matches any participant.
+func matches(item map[string]any, c Condition) bool {
+	for _, v := range lookup(item, c.Field) {
+		switch c.Op {
+		case "in":
+			for _, want := range c.Values {
+				if compare(v, want) == 0 {
+					return true
+				}
+			}
+		case "=":
+			if compare(v, c.Values[0]) == 0 {
+				return true
+			}
+		case "!=":
+			if compare(v, c.Values[0]) != 0 {
+				return true
+			}
+		case "<":
+			if compare(v, c.Values[0]) < 0 {
+				return true
+			}
+		case "<=":
+			if compare(v, c.Values[0]) <= 0 {
+				return true
+			}
+		case ">":
+			if compare(v, c.Values[0]) > 0 {
+				return true
+			}
+		case ">=":
+			if compare(v, c.Values[0]) >= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup returns the values at a dotted path, flattening arrays.
+func lookup(v any, path string) []any {
+	if path == "" {
+		if arr, ok := v.([]any); ok {
+			return arr
+		}
+		return []any{v}
+	}
+	head, rest, _ := strings.Cut(path, ".")
+	switch x := v.(type) {
+	case map[string]any:
+		next, ok := x[head]
+		if !ok {
+			return nil
+		}
+		return lookup(next, rest)
+	case []any:
+		var out []any
+		for _, e := range x {
+			out = append(out, lookup(e, path)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func first(vs []any) any {
+	if len(vs) == 0 {
+		return nil
+	}
+	return vs[0]
+}
+
+// compare compares a JSON value with a filter value: numerically when both
+// are numbers, otherwise as strings (RFC 3339 UTC times sort correctly).
+func compare(v any, want string) int {
+	got := formatScalar(v)
+	if a, err := strconv.ParseFloat(got, 64); err == nil {
+		if b, err := strconv.ParseFloat(want, 64); err == nil {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(got, want)
+}
+
+func formatScalar(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
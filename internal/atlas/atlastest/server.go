@@ -0,0 +1,297 @@
+// Package atlastest provides an in-process fake of the Atlas v3 API for tests
+// and local development. It serves resources such as /series, /rosters,
+// /players, /teams, /tournaments and /matches from in-memory fixtures, with
+// skip/take pagination, the Atlas filter and order syntax, X-RateLimit-*
+// headers and scriptable faults.
+//
+//	srv := atlastest.NewServer()
+//	defer srv.Close()
+//	srv.AddSeries(atlas.Series{ID: 1, Lifecycle: "live"})
+//	client := atlas.NewClientWithURL("test-secret", srv.URL)
+package atlastest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/atlas"
+)
+
+// maxTake is the largest page size Atlas accepts.
+const maxTake = 50
+
+// Fault makes matching requests fail instead of being served.
+type Fault struct {
+	Path         string // resource path to match, e.g. "/rosters"; "" matches any
+	Status       int    // response status, e.g. 429 or 503
+	RetryAfterMs int    // Retry-After header value for 429
+	Times        int    // number of requests to fail; 0 means 1
+}
+
+// Server is a fake Atlas API. Use NewServer; Close when done.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	resources map[string][]map[string]any
+	faults    []Fault
+	requests  []string
+	rateLimit atlas.RateLimit
+}
+
+// NewServer starts a fake Atlas API with no fixtures. Responses carry
+// X-RateLimit-* headers for a generous limit until SetRateLimit is called.
+func NewServer() *Server {
+	s := &Server{
+		resources: make(map[string][]map[string]any),
+		rateLimit: atlas.RateLimit{Limit: 1000, Burst: 1000, Remaining: 1000},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Add appends fixtures to resource (e.g. "/games"). Items are stored as their
+// JSON representation, so typed models and maps are both accepted.
+func (s *Server) Add(resource string, items ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			panic(fmt.Sprintf("atlastest: marshal fixture: %v", err))
+		}
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			panic(fmt.Sprintf("atlastest: fixture is not a JSON object: %v", err))
+		}
+		s.resources[resource] = append(s.resources[resource], m)
+	}
+}
+
+// Reset removes all fixtures of resource.
+func (s *Server) Reset(resource string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.resources, resource)
+}
+
+// AddSeries adds series fixtures.
+func (s *Server) AddSeries(series ...atlas.Series) { s.Add("/series", toAny(series)...) }
+
+// AddRosters adds roster fixtures.
+func (s *Server) AddRosters(rosters ...atlas.Roster) { s.Add("/rosters", toAny(rosters)...) }
+
+// AddPlayers adds player fixtures.
+func (s *Server) AddPlayers(players ...atlas.Player) { s.Add("/players", toAny(players)...) }
+
+// AddTeams adds team fixtures.
+func (s *Server) AddTeams(teams ...atlas.Team) { s.Add("/teams", toAny(teams)...) }
+
+// AddGames adds game fixtures.
+func (s *Server) AddGames(games ...atlas.Game) { s.Add("/games", toAny(games)...) }
+
+// AddTournaments adds tournament fixtures.
+func (s *Server) AddTournaments(tournaments ...atlas.Tournament) {
+	s.Add("/tournaments", toAny(tournaments)...)
+}
+
+// AddStages adds stage fixtures.
+func (s *Server) AddStages(stages ...atlas.Stage) { s.Add("/stages", toAny(stages)...) }
+
+// AddSubstages adds substage fixtures.
+func (s *Server) AddSubstages(substages ...atlas.Substage) { s.Add("/substages", toAny(substages)...) }
+
+// AddMatches adds match fixtures.
+func (s *Server) AddMatches(matches ...atlas.Match) { s.Add("/matches", toAny(matches)...) }
+
+// SetRateLimit sets the X-RateLimit-* headers sent on every response.
+// A zero Limit omits the headers.
+func (s *Server) SetRateLimit(rl atlas.RateLimit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = rl
+}
+
+// Inject queues a fault. Faults are applied in the order they were injected.
+func (s *Server) Inject(f Fault) {
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, f)
+}
+
+// Requests returns the path and query of every request received so far.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	fault, faulted := s.takeFaultLocked(r.URL.Path)
+	rl := s.rateLimit
+	items, known := s.resources[r.URL.Path]
+	items = append([]map[string]any(nil), items...)
+	s.mu.Unlock()
+
+	if rl.Limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.Limit))
+		w.Header().Set("X-RateLimit-Burst", strconv.Itoa(rl.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(rl.ResetMs))
+	}
+	if r.Header.Get("Abios-Secret") == "" {
+		writeError(w, http.StatusUnauthorized, "missing Abios-Secret")
+		return
+	}
+	if faulted {
+		if fault.Status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfterMs))
+		}
+		writeError(w, fault.Status, http.StatusText(fault.Status))
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return
+	}
+	if !known && !isKnownResource(r.URL.Path) {
+		writeError(w, http.StatusNotFound, "unknown resource")
+		return
+	}
+
+	q := r.URL.Query()
+	skip, take, err := pageParams(q.Get("skip"), q.Get("take"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if f := q.Get("filter"); f != "" {
+		conds, err := ParseFilter(f)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		items = filterItems(items, conds)
+	}
+	if o := q.Get("order"); o != "" {
+		if err := orderItems(items, o); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	page := []map[string]any{}
+	if skip < len(items) {
+		page = items[skip:min(skip+take, len(items))]
+	}
+	if f := q.Get("fields"); f != "" {
+		page = selectFields(page, strings.Split(f, ","))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func (s *Server) takeFaultLocked(path string) (Fault, bool) {
+	for i, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+		s.faults[i].Times--
+		if s.faults[i].Times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return f, true
+	}
+	return Fault{}, false
+}
+
+// isKnownResource reports whether path is a collection GameHub reads, so an
+// empty fixture set answers [] rather than 404.
+func isKnownResource(path string) bool {
+	switch path {
+	case "/series", "/rosters", "/players", "/teams", "/games", "/tournaments",
+		"/stages", "/substages", "/matches":
+		return true
+	}
+	return false
+}
+
+func pageParams(skipStr, takeStr string) (skip, take int, err error) {
+	take = maxTake
+	if skipStr != "" {
+		if skip, err = strconv.Atoi(skipStr); err != nil || skip < 0 {
+			return 0, 0, fmt.Errorf("invalid skip %q", skipStr)
+		}
+	}
+	if takeStr != "" {
+		if take, err = strconv.Atoi(takeStr); err != nil || take < 0 || take > maxTake {
+			return 0, 0, fmt.Errorf("invalid take %q: must be in [0,%d]", takeStr, maxTake)
+		}
+	}
+	return skip, take, nil
+}
+
+func orderItems(items []map[string]any, order string) error {
+	type key struct {
+		field string
+		desc  bool
+	}
+	var keys []key
+	for _, part := range strings.Split(order, ",") {
+		field, dir, ok := strings.Cut(part, "-")
+		if !ok || (dir != "asc" && dir != "desc") {
+			return fmt.Errorf("invalid order %q", part)
+		}
+		keys = append(keys, key{field: field, desc: dir == "desc"})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, k := range keys {
+			a, b := first(lookup(items[i], k.field)), first(lookup(items[j], k.field))
+			c := compare(a, formatScalar(b))
+			if c == 0 {
+				continue
+			}
+			return (c < 0) != k.desc
+		}
+		return false
+	})
+	return nil
+}
+
+func selectFields(items []map[string]any, fields []string) []map[string]any {
+	out := make([]map[string]any, len(items))
+	for i, item := range items {
+		m := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if v, ok := item[f]; ok {
+				m[f] = v
+			}
+		}
+		out[i] = m
+	}
+	return out
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func toAny[T any](items []T) []any {
+	out := make([]any, len(items))
+	for i, item := range items {
+		out[i] = item
+	}
+	return out
+}
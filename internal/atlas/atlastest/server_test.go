@@ -0,0 +1,198 @@
+package atlastest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aaron/gamehub/internal/atlas"
+)
+
+func TestServer_PaginatesAndFilters(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	for i := 1; i <= 120; i++ {
+		lifecycle := "over"
+		if i%2 == 0 {
+			lifecycle = "live"
+		}
+		srv.AddSeries(atlas.Series{ID: i, Lifecycle: lifecycle})
+	}
+
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	series, _, err := client.SeriesAll(context.Background(), atlas.NewQuery().Eq("lifecycle", "live").Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 60 {
+		t.Fatalf("want 60 live series, got %d", len(series))
+	}
+	for i, s := range series {
+		if s.ID != (i+1)*2 {
+			t.Fatalf("series[%d].ID = %d, want %d", i, s.ID, (i+1)*2)
+		}
+	}
+}
+
+func TestServer_FilterOperators(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddRosters(
+		atlas.Roster{ID: 1, Team: atlas.Ref{ID: 10}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 100}, {ID: 101}}}},
+		atlas.Roster{ID: 2, Team: atlas.Ref{ID: 20}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 200}}}},
+		atlas.Roster{ID: 3, Team: atlas.Ref{ID: 30}},
+	)
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+
+	tests := []struct {
+		name string
+		q    *atlas.Query
+		want []int
+	}{
+		{"in", atlas.NewQuery().InInts("id", []int{1, 3, 99}), []int{1, 3}},
+		{"nested eq", atlas.NewQuery().Eq("team.id", 20), []int{2}},
+		{"array path", atlas.NewQuery().Eq("line_up.players.id", 101), []int{1}},
+		{"comparison", atlas.NewQuery().Gt("id", 1).Lte("id", 3), []int{2, 3}},
+		{"ne", atlas.NewQuery().Ne("id", 2), []int{1, 3}},
+		{"order desc", atlas.NewQuery().OrderBy("id", atlas.Desc), []int{3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rosters, _, err := client.RostersAll(context.Background(), tt.q.Params())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int
+			for _, r := range rosters {
+				got = append(got, r.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_InjectedFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Inject(Fault{Path: "/teams", Status: http.StatusServiceUnavailable, Times: 2})
+	srv.Inject(Fault{Status: http.StatusTooManyRequests, RetryAfterMs: 5})
+
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	ctx := atlas.WithRetryPolicy(context.Background(), atlas.NoRetry)
+
+	// Any path: the 429 fault applies, /teams fault is skipped.
+	_, _, err := client.Get(ctx, "/players")
+	var rlErr *atlas.ErrRateLimited
+	if !errors.As(err, &rlErr) || rlErr.RetryAfterMs != 5 {
+		t.Fatalf("want ErrRateLimited(5ms), got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, _, err := client.Get(ctx, "/teams")
+		var apiErr *atlas.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("/teams call %d: want 503, got %v", i+1, err)
+		}
+	}
+	if _, _, err := client.Get(ctx, "/teams"); err != nil {
+		t.Fatalf("after faults: want success, got %v", err)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Errorf("want 4 recorded requests, got %d", n)
+	}
+}
+
+func TestServer_RateLimitHeadersAndValidation(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetRateLimit(atlas.RateLimit{Limit: 5, Burst: 10, Remaining: 7, ResetMs: 200})
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/series?take=51", nil)
+	req.Header.Set("Abios-Secret", "x")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("take=51: want 400, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "7" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 7", got)
+	}
+
+	resp, err = http.Get(srv.URL + "/series")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("no secret: want 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestParseFilter_Escapes(t *testing.T) {
+	q := atlas.NewQuery().Eq("title", `a,b{c}\d`).In("tag", "x,y", "z")
+	conds, err := ParseFilter(q.Filter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conds) != 2 {
+		t.Fatalf("want 2 conditions, got %d: %+v", len(conds), conds)
+	}
+	if conds[0].Op != "=" || conds[0].Values[0] != `a,b{c}\d` {
+		t.Errorf("condition 0 = %+v", conds[0])
+	}
+	got, _ := json.Marshal(conds[1].Values)
+	if conds[1].Op != "in" || string(got) != `["x,y","z"]` {
+		t.Errorf("condition 1 = %+v", conds[1])
+	}
+	if _, err := ParseFilter("id~1"); err == nil {
+		t.Error("want error for unknown operator")
+	}
+}
+
+func TestServer_TypedResourceAccessors(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddGames(atlas.Game{ID: 1, Title: "CS2"})
+	srv.AddTournaments(atlas.Tournament{ID: 2, Title: "Major", Stages: []atlas.Ref{{ID: 3}}})
+	srv.AddStages(atlas.Stage{ID: 3, Tournament: atlas.Ref{ID: 2}, Substages: []atlas.Ref{{ID: 4}}})
+	srv.AddSubstages(atlas.Substage{ID: 4, Stage: atlas.Ref{ID: 3}, Series: []atlas.Ref{{ID: 5}}})
+	srv.AddMatches(atlas.Match{ID: 6, Order: 1, Series: atlas.Ref{ID: 5}}, atlas.Match{ID: 7, Series: atlas.Ref{ID: 99}})
+
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	ctx := context.Background()
+	games, _, err := client.GamesAll(ctx, nil)
+	if err != nil || len(games) != 1 || games[0].Title != "CS2" {
+		t.Errorf("GamesAll = %+v, %v", games, err)
+	}
+	tournaments, _, err := client.TournamentsAll(ctx, nil)
+	if err != nil || len(tournaments) != 1 || tournaments[0].Stages[0].ID != 3 {
+		t.Errorf("TournamentsAll = %+v, %v", tournaments, err)
+	}
+	stages, _, err := client.StagesAll(ctx, atlas.NewQuery().Eq("tournament.id", 2).Params())
+	if err != nil || len(stages) != 1 || stages[0].Substages[0].ID != 4 {
+		t.Errorf("StagesAll = %+v, %v", stages, err)
+	}
+	substages, _, err := client.SubstagesAll(ctx, nil)
+	if err != nil || len(substages) != 1 || substages[0].Series[0].ID != 5 {
+		t.Errorf("SubstagesAll = %+v, %v", substages, err)
+	}
+	matches, _, err := client.MatchesAll(ctx, atlas.NewQuery().Eq("series.id", 5).Params())
+	if err != nil || len(matches) != 1 || matches[0].ID != 6 {
+		t.Errorf("MatchesAll = %+v, %v", matches, err)
+	}
+	byID, err := client.TournamentsByIDs(ctx, []int{2, 404}, nil)
+	if err != nil || len(byID) != 1 || byID[0].ID != 2 {
+		t.Errorf("TournamentsByIDs = %+v, %v", byID, err)
+	}
+}
@@ -0,0 +1,210 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// BreakerState is the state of the Atlas circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // requests flow; outcomes are tracked
+	BreakerOpen                         // requests fail fast with ErrCircuitOpen
+	BreakerHalfOpen                     // a limited number of probes are let through
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// ErrCircuitOpen is returned without contacting Atlas while the breaker is open.
+type ErrCircuitOpen struct {
+	RetryAfter time.Duration // until the breaker lets a probe through
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("atlas circuit open: retry after %v", e.RetryAfter)
+}
+
+// breaker trips when the share of failed or slow calls among the last window
+// outcomes reaches failurePct. After openFor it lets up to probes requests
+// through; if they all succeed it closes, if one fails it opens again.
+type breaker struct {
+	window     int
+	failurePct int
+	slowCall   time.Duration
+	openFor    time.Duration
+	probes     int
+
+	mu        sync.Mutex
+	state     BreakerState
+	gen       uint64 // bumped on every transition; stale outcomes are ignored
+	outcomes  []bool // ring of recent outcomes, true = failure
+	next      int
+	openedAt  time.Time
+	inFlight  int // probes sent in half-open
+	succeeded int // probes succeeded in half-open
+}
+
+func newBreaker() *breaker {
+	b := &breaker{
+		window:     config.AtlasBreakerWindow(),
+		failurePct: config.AtlasBreakerFailurePct(),
+		slowCall:   config.AtlasBreakerSlowCall(),
+		openFor:    config.AtlasBreakerOpenFor(),
+		probes:     config.AtlasBreakerProbes(),
+	}
+	metrics.SetAtlasBreakerState(b.state.String())
+	return b
+}
+
+// current returns the state (open turns half-open once openFor elapsed).
+func (b *breaker) current() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(time.Now())
+	return b.state
+}
+
+// allow reports whether a request may be sent now. Every allowed request must
+// be followed by exactly one record call with the returned generation.
+func (b *breaker) allow(now time.Time) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(now)
+	switch b.state {
+	case BreakerOpen:
+		metrics.AtlasBreakerRejected.Add(1)
+		return 0, &ErrCircuitOpen{RetryAfter: b.openedAt.Add(b.openFor).Sub(now)}
+	case BreakerHalfOpen:
+		if b.inFlight+b.succeeded >= b.probes {
+			metrics.AtlasBreakerRejected.Add(1)
+			return 0, &ErrCircuitOpen{RetryAfter: time.Second}
+		}
+		b.inFlight++
+	}
+	return b.gen, nil
+}
+
+// record reports the outcome of a request allowed in generation gen.
+// Outcomes from before the last transition are ignored.
+func (b *breaker) record(now time.Time, gen uint64, err error, latency time.Duration) {
+	counted, failed := classifyOutcome(err)
+	if counted && !failed && b.slowCall > 0 && latency >= b.slowCall {
+		failed = true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen {
+		return
+	}
+	switch b.state {
+	case BreakerHalfOpen:
+		b.inFlight--
+		if !counted {
+			return
+		}
+		if failed {
+			b.transitionLocked(now, BreakerOpen)
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.transitionLocked(now, BreakerClosed)
+		}
+	case BreakerClosed:
+		if !counted {
+			return
+		}
+		if len(b.outcomes) < b.window {
+			b.outcomes = append(b.outcomes, failed)
+		} else {
+			b.outcomes[b.next] = failed
+			b.next = (b.next + 1) % b.window
+		}
+		if b.shouldTripLocked() {
+			b.transitionLocked(now, BreakerOpen)
+		}
+	}
+}
+
+// release gives back a request allowed in generation gen that was never sent,
+// freeing its half-open probe slot without recording an outcome.
+func (b *breaker) release(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen == b.gen && b.state == BreakerHalfOpen {
+		b.inFlight--
+	}
+}
+
+func (b *breaker) shouldTripLocked() bool {
+	if len(b.outcomes) < max(b.window/2, 1) {
+		return false
+	}
+	failures := 0
+	for _, f := range b.outcomes {
+		if f {
+			failures++
+		}
+	}
+	return failures*100 >= b.failurePct*len(b.outcomes)
+}
+
+func (b *breaker) advanceLocked(now time.Time) {
+	if b.state == BreakerOpen && !now.Before(b.openedAt.Add(b.openFor)) {
+		b.transitionLocked(now, BreakerHalfOpen)
+	}
+}
+
+func (b *breaker) transitionLocked(now time.Time, to BreakerState) {
+	log.Printf("atlas circuit breaker: %s -> %s", b.state, to)
+	b.state = to
+	b.gen++
+	b.outcomes = b.outcomes[:0]
+	b.next = 0
+	b.inFlight = 0
+	b.succeeded = 0
+	if to == BreakerOpen {
+		b.openedAt = now
+		metrics.AtlasBreakerOpens.Add(1)
+	}
+	metrics.SetAtlasBreakerState(to.String())
+}
+
+// classifyOutcome reports whether err says something about Atlas health
+// (counted) and whether it is a failure. 4xx means Atlas is up; 429,
+// cancellations, a caller's deadline expiring before the exchange and a
+// request missing from a replay are neutral. A timeout during the exchange is
+// wrapped in ErrTimeout and counts.
+func classifyOutcome(err error) (counted, failed bool) {
+	if err == nil {
+		return true, false
+	}
+	var rlErr *ErrRateLimited
+	if errors.As(err, &rlErr) || errors.Is(err, context.Canceled) || err == context.DeadlineExceeded ||
+		errors.Is(err, ErrNoCassette) {
+		return false, false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return true, apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true, true
+}
@@ -0,0 +1,191 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testBreaker() *breaker {
+	return &breaker{window: 4, failurePct: 50, slowCall: 100 * time.Millisecond, openFor: time.Second, probes: 2}
+}
+
+var errUpstream = &APIError{StatusCode: http.StatusBadGateway}
+
+func TestBreaker_OpensOnFailureRate(t *testing.T) {
+	b := testBreaker()
+	now := time.Now()
+	for _, err := range []error{nil, nil, errUpstream, errUpstream} {
+		gen, aerr := b.allow(now)
+		if aerr != nil {
+			t.Fatalf("allow while closed: %v", aerr)
+		}
+		b.record(now, gen, err, time.Millisecond)
+	}
+	if b.current() != BreakerOpen {
+		t.Fatalf("state: want open at 50%% failures, got %s", b.current())
+	}
+	_, err := b.allow(now.Add(100 * time.Millisecond))
+	var openErr *ErrCircuitOpen
+	if !errors.As(err, &openErr) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+	if openErr.RetryAfter != 900*time.Millisecond {
+		t.Errorf("RetryAfter: want 900ms, got %v", openErr.RetryAfter)
+	}
+}
+
+func TestBreaker_ClientErrorsAndRateLimitsDoNotTrip(t *testing.T) {
+	b := testBreaker()
+	now := time.Now()
+	for _, err := range []error{
+		&APIError{StatusCode: http.StatusNotFound},
+		&ErrRateLimited{RetryAfterMs: 100},
+		context.Canceled,
+		&APIError{StatusCode: http.StatusBadRequest},
+	} {
+		gen, _ := b.allow(now)
+		b.record(now, gen, err, time.Millisecond)
+	}
+	if s := b.current(); s != BreakerClosed {
+		t.Errorf("state: want closed, got %s", s)
+	}
+}
+
+func TestBreaker_SlowCallsCountAsFailures(t *testing.T) {
+	b := testBreaker()
+	now := time.Now()
+	for range 2 {
+		gen, _ := b.allow(now)
+		b.record(now, gen, nil, 200*time.Millisecond)
+	}
+	if s := b.current(); s != BreakerOpen {
+		t.Errorf("state: want open after slow calls, got %s", s)
+	}
+}
+
+func TestBreaker_HalfOpenProbes(t *testing.T) {
+	b := testBreaker()
+	now := time.Now()
+	for range 2 {
+		gen, _ := b.allow(now)
+		b.record(now, gen, errUpstream, time.Millisecond)
+	}
+	later := now.Add(time.Second)
+
+	// Half-open: two probes allowed, third rejected.
+	g1, err1 := b.allow(later)
+	g2, err2 := b.allow(later)
+	_, err3 := b.allow(later)
+	if err1 != nil || err2 != nil || err3 == nil {
+		t.Fatalf("probes: want allow, allow, reject; got %v, %v, %v", err1, err2, err3)
+	}
+	b.record(later, g1, nil, time.Millisecond)
+	if s := b.current(); s != BreakerHalfOpen {
+		t.Fatalf("after 1 probe success: want half-open, got %s", s)
+	}
+	b.record(later, g2, nil, time.Millisecond)
+	if s := b.current(); s != BreakerClosed {
+		t.Fatalf("after 2 probe successes: want closed, got %s", s)
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	b := testBreaker()
+	now := time.Now()
+	for range 2 {
+		gen, _ := b.allow(now)
+		b.record(now, gen, errUpstream, time.Millisecond)
+	}
+	later := now.Add(time.Second)
+	gen, err := b.allow(later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.record(later, gen, errors.New("connection reset"), time.Millisecond)
+	if _, err := b.allow(later); err == nil {
+		t.Error("want ErrCircuitOpen after failed probe")
+	}
+}
+
+func TestGet_FailsFastWhileCircuitOpen(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	client.breaker = testBreaker()
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	for range 2 {
+		_, _, _ = client.Get(ctx, "/series")
+	}
+	if s := client.BreakerState(); s != BreakerOpen {
+		t.Fatalf("state: want open, got %s", s)
+	}
+	_, _, err := client.Get(ctx, "/series")
+	var openErr *ErrCircuitOpen
+	if !errors.As(err, &openErr) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("upstream requests: want 2 (third failed fast), got %d", n)
+	}
+}
+
+func TestBreaker_ReleaseFreesProbeSlot(t *testing.T) {
+	b := testBreaker()
+	b.transitionLocked(time.Now(), BreakerOpen)
+	later := time.Now().Add(2 * time.Second)
+	for range 2 {
+		gen, err := b.allow(later)
+		if err != nil {
+			t.Fatalf("allow probe: %v", err)
+		}
+		b.release(gen)
+	}
+	if _, err := b.allow(later); err != nil {
+		t.Errorf("released probes still hold their slots: %v", err)
+	}
+	if s := b.current(); s != BreakerHalfOpen {
+		t.Errorf("state: want half-open, got %s", s)
+	}
+}
+
+func TestGet_LocalBackoffDeadlineDoesNotTrip(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "60000")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	client.breaker = testBreaker()
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	var rlErr *ErrRateLimited
+	if _, _, err := client.Get(ctx, "/series"); !errors.As(err, &rlErr) {
+		t.Fatalf("first call: want ErrRateLimited, got %v", err)
+	}
+	for range 20 {
+		dctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+		_, _, err := client.Get(dctx, "/series")
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want DeadlineExceeded while backing off, got %v", err)
+		}
+	}
+	if s := client.BreakerState(); s != BreakerClosed {
+		t.Errorf("state: want closed (Atlas was not contacted), got %s", s)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("upstream requests: want 1, got %d", n)
+	}
+}
@@ -0,0 +1,167 @@
+package atlas
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cassettes are recorded request/response pairs, one JSON file per request
+// (method + path + query) in a fixture directory. A recorder writes them while
+// talking to Atlas; a replayer serves them back without network access. The
+// Abios-Secret header is never recorded.
+
+// ErrNoCassette is returned by a replayer for a request that was not recorded,
+// such as a speculative page fetch cancelled while recording. It says nothing
+// about Atlas, so it neither counts against the circuit breaker nor is retried.
+var ErrNoCassette = errors.New("atlas replay: no cassette")
+
+// cassetteHeaders are the response headers kept in a cassette.
+var cassetteHeaders = []string{
+	"Content-Type", "ETag", "Last-Modified", "Retry-After",
+	"X-RateLimit-Limit", "X-RateLimit-Burst", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+type cassette struct {
+	Request struct {
+		Method string `json:"method"`
+		URI    string `json:"uri"`
+	} `json:"request"`
+	Response struct {
+		Status int               `json:"status"`
+		Header map[string]string `json:"header,omitempty"`
+		Body   string            `json:"body"`
+	} `json:"response"`
+}
+
+// cassetteFile returns the fixture file for a request: a readable prefix from
+// the path plus a hash of method and full URI.
+func cassetteFile(dir string, req *http.Request) string {
+	uri := req.URL.RequestURI()
+	sum := sha256.Sum256([]byte(req.Method + " " + uri))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.Trim(req.URL.Path, "/"))
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.json", name, hex.EncodeToString(sum[:6])))
+}
+
+// recorder passes requests to next and writes each response to a cassette.
+type recorder struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// NewRecorder returns a RoundTripper that sends requests through next
+// (http.DefaultTransport if nil) and records every response to dir. A 304 keeps
+// the previously recorded full response, so replays never depend on a cache.
+func NewRecorder(dir string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recorder{dir: dir, next: next}
+}
+
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusNotModified {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var c cassette
+	c.Request.Method = req.Method
+	c.Request.URI = req.URL.RequestURI()
+	c.Response.Status = resp.StatusCode
+	c.Response.Header = make(map[string]string)
+	for _, h := range cassetteHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			c.Response.Header[h] = v
+		}
+	}
+	c.Response.Body = string(body)
+	if err := writeCassette(cassetteFile(r.dir, req), &c); err != nil {
+		return nil, fmt.Errorf("atlas record: %w", err)
+	}
+	return resp, nil
+}
+
+// writeCassette writes c atomically (temp file + rename).
+func writeCassette(path string, c *cassette) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cassette-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// replayer serves recorded cassettes.
+type replayer struct {
+	dir string
+}
+
+// NewReplayer returns a RoundTripper that answers requests from cassettes in
+// dir and never touches the network. A request without a cassette fails.
+func NewReplayer(dir string) http.RoundTripper {
+	return &replayer{dir: dir}
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	b, err := os.ReadFile(cassetteFile(r.dir, req))
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s %s: %w", ErrNoCassette, req.Method, req.URL.RequestURI(), err)
+	}
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("atlas replay: %w", err)
+	}
+	header := make(http.Header, len(c.Response.Header))
+	for k, v := range c.Response.Header {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Response.Status, http.StatusText(c.Response.Status)),
+		StatusCode:    c.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(c.Response.Body)),
+		ContentLength: int64(len(c.Response.Body)),
+		Request:       req,
+	}, nil
+}
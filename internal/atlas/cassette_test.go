@@ -0,0 +1,152 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Burst", "10")
+		w.Header().Set("X-RateLimit-Remaining", "9")
+		if r.URL.Query().Get("filter") == "id=2" {
+			_, _ = w.Write([]byte(`[{"id":2}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":1}]`))
+	}))
+
+	t.Setenv("GAMEHUB_ATLAS_RECORD_DIR", dir)
+	rec := NewClientWithURL("test-secret", server.URL)
+	for _, filter := range []string{"id=1", "id=2"} {
+		if _, _, err := rec.GetSeries(context.Background(), map[string]string{"filter": filter}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("want 2 cassettes, got %d", len(files))
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "test-secret") {
+			t.Errorf("%s contains the API key", f)
+		}
+	}
+
+	t.Setenv("GAMEHUB_ATLAS_RECORD_DIR", "")
+	t.Setenv("GAMEHUB_ATLAS_REPLAY_DIR", dir)
+	replay := NewClientWithURL("other-secret", server.URL)
+	body, rl, err := replay.GetSeries(context.Background(), map[string]string{"filter": "id=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `[{"id":2}]` {
+		t.Errorf("replayed body %s", body)
+	}
+	if rl == nil || rl.Remaining != 9 {
+		t.Errorf("replayed rate limit %+v", rl)
+	}
+
+	_, _, err = replay.GetSeries(context.Background(), map[string]string{"filter": "id=3"})
+	if !errors.Is(err, ErrNoCassette) {
+		t.Errorf("unrecorded request: want ErrNoCassette, got %v", err)
+	}
+}
+
+func TestCassette_ReplaysParallelPagination(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+		if skip >= 2*take {
+			// Speculative pages past the short one are cancelled, not recorded.
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		items := []map[string]int{}
+		for i := skip; i < min(skip+take, 60); i++ {
+			items = append(items, map[string]int{"id": i + 1})
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	defer server.Close()
+
+	t.Setenv("GAMEHUB_ATLAS_RECORD_DIR", dir)
+	rec := NewClientWithURL("test-secret", server.URL)
+	rec.SetPageConcurrency(4)
+	if _, _, err := rec.SeriesAll(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) < 2 {
+		t.Fatalf("want the first two pages recorded, got %d cassettes", len(files))
+	}
+
+	t.Setenv("GAMEHUB_ATLAS_RECORD_DIR", "")
+	t.Setenv("GAMEHUB_ATLAS_REPLAY_DIR", dir)
+	replay := NewClientWithURL("other-secret", server.URL)
+	replay.SetPageConcurrency(4)
+	for i := range 5 {
+		series, _, err := replay.SeriesAll(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("replay %d: %v", i+1, err)
+		}
+		if len(series) != 60 {
+			t.Fatalf("replay %d: %d series, want 60", i+1, len(series))
+		}
+	}
+	if s := replay.BreakerState(); s != BreakerClosed {
+		t.Errorf("breaker %s after replays with missing speculative pages, want closed", s)
+	}
+}
+
+func TestCassette_RecorderKeepsFullResponseOn304(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GAMEHUB_ATLAS_RECORD_DIR", dir)
+	client := NewClientWithURL("test-secret", server.URL)
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay := NewReplayer(dir)
+	req := httptest.NewRequest(http.MethodGet, server.URL+"/series", nil)
+	resp, err := replay.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("replayed status %d, want 200", resp.StatusCode)
+	}
+}
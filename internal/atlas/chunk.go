@@ -0,0 +1,177 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/config"
+)
+
+// ItemsByIDs returns an iterator over the items of path whose id is in ids,
+// further restricted by base (may be nil). Large ID sets are split into chunks
+// of AtlasIDChunkSize so each filter stays short; chunks are fetched with
+// bounded concurrency, yielded in chunk order and deduped by id, so callers
+// see a single logical query.
+func (c *Client) ItemsByIDs(ctx context.Context, path string, ids []int, base *Query) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		chunks := chunkIDs(uniqueIDs(ids), config.AtlasIDChunkSize())
+		if len(chunks) == 0 {
+			return
+		}
+		var wg sync.WaitGroup
+		defer wg.Wait() // after cancel below: stop in-flight chunks, then wait
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			items []json.RawMessage
+			err   error
+		}
+		results := make([]result, len(chunks))
+		done := make([]chan struct{}, len(chunks))
+		for i := range done {
+			done[i] = make(chan struct{})
+		}
+		sem := make(chan struct{}, config.AtlasIDChunkConcurrency())
+		for i, chunk := range chunks {
+			wg.Go(func() {
+				defer close(done[i])
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					results[i].err = ctx.Err()
+					return
+				}
+				defer func() { <-sem }()
+				q := base.Clone().InInts("id", chunk)
+				results[i].items, _, results[i].err = fetchAll[json.RawMessage](ctx, c, path, q.Params())
+			})
+		}
+
+		seen := make(map[int]bool)
+		for i := range chunks {
+			<-done[i]
+			if err := results[i].err; err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range results[i].items {
+				if id := itemID(item); id != 0 {
+					if seen[id] {
+						continue
+					}
+					seen[id] = true
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+			results[i].items = nil
+		}
+	}
+}
+
+// getAllByIDs collects ItemsByIDs into a JSON array.
+func (c *Client) getAllByIDs(ctx context.Context, path string, ids []int, base *Query) ([]byte, error) {
+	all := []json.RawMessage{}
+	for item, err := range c.ItemsByIDs(ctx, path, ids, base) {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return json.Marshal(all)
+}
+
+// fetchByIDs collects ItemsByIDs decoded into []T.
+func fetchByIDs[T any](ctx context.Context, c *Client, path string, ids []int, base *Query) ([]T, error) {
+	all := []T{}
+	for item, err := range c.ItemsByIDs(ctx, path, ids, base) {
+		if err != nil {
+			return nil, err
+		}
+		var v T
+		if err := json.Unmarshal(item, &v); err != nil {
+			return nil, decodeError(path, err)
+		}
+		all = append(all, v)
+	}
+	return all, nil
+}
+
+// GetPlayersByIDs fetches players by ID as a JSON array (see ItemsByIDs).
+func (c *Client) GetPlayersByIDs(ctx context.Context, ids []int, base *Query) ([]byte, error) {
+	return c.getAllByIDs(ctx, "/players", ids, base)
+}
+
+// GetTeamsByIDs fetches teams by ID as a JSON array (see ItemsByIDs).
+func (c *Client) GetTeamsByIDs(ctx context.Context, ids []int, base *Query) ([]byte, error) {
+	return c.getAllByIDs(ctx, "/teams", ids, base)
+}
+
+// GetRostersByIDs fetches rosters by ID as a JSON array (see ItemsByIDs).
+func (c *Client) GetRostersByIDs(ctx context.Context, ids []int, base *Query) ([]byte, error) {
+	return c.getAllByIDs(ctx, "/rosters", ids, base)
+}
+
+// GetTournamentsByIDs fetches tournaments by ID as a JSON array (see ItemsByIDs).
+func (c *Client) GetTournamentsByIDs(ctx context.Context, ids []int, base *Query) ([]byte, error) {
+	return c.getAllByIDs(ctx, "/tournaments", ids, base)
+}
+
+// PlayersByIDs fetches players by ID, decoded into typed models.
+func (c *Client) PlayersByIDs(ctx context.Context, ids []int, base *Query) ([]Player, error) {
+	return fetchByIDs[Player](ctx, c, "/players", ids, base)
+}
+
+// TeamsByIDs fetches teams by ID, decoded into typed models.
+func (c *Client) TeamsByIDs(ctx context.Context, ids []int, base *Query) ([]Team, error) {
+	return fetchByIDs[Team](ctx, c, "/teams", ids, base)
+}
+
+// RostersByIDs fetches rosters by ID, decoded into typed models.
+func (c *Client) RostersByIDs(ctx context.Context, ids []int, base *Query) ([]Roster, error) {
+	return fetchByIDs[Roster](ctx, c, "/rosters", ids, base)
+}
+
+// TournamentsByIDs fetches tournaments by ID, decoded into typed models.
+func (c *Client) TournamentsByIDs(ctx context.Context, ids []int, base *Query) ([]Tournament, error) {
+	return fetchByIDs[Tournament](ctx, c, "/tournaments", ids, base)
+}
+
+// itemID returns the "id" of a JSON object, or 0 if absent.
+func itemID(item json.RawMessage) int {
+	var v struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(item, &v); err != nil {
+		return 0
+	}
+	return v.ID
+}
+
+// uniqueIDs returns ids without duplicates, keeping first occurrences in order.
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	out := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// chunkIDs splits ids into consecutive chunks of at most size.
+func chunkIDs(ids []int, size int) [][]int {
+	var chunks [][]int
+	for len(ids) > 0 {
+		n := min(size, len(ids))
+		chunks = append(chunks, ids[:n:n])
+		ids = ids[n:]
+	}
+	return chunks
+}
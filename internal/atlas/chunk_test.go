@@ -0,0 +1,120 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// idFilterServer answers id<={...} filters with {"id":n} for each requested id,
+// plus id 1 on every response to exercise dedupe across chunks.
+func idFilterServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var filters []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter := r.URL.Query().Get("filter")
+		mu.Lock()
+		filters = append(filters, filter)
+		mu.Unlock()
+		items := []map[string]int{{"id": 1}}
+		inner := strings.TrimSuffix(strings.TrimPrefix(filter, "id<={"), "}")
+		for _, s := range strings.Split(inner, ",") {
+			if n, err := strconv.Atoi(s); err == nil && n != 1 {
+				items = append(items, map[string]int{"id": n})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), filters...)
+	}
+}
+
+func TestItemsByIDs_ChunksMergesAndDedupes(t *testing.T) {
+	t.Setenv("GAMEHUB_ATLAS_ID_CHUNK_SIZE", "3")
+	srv, filters := idFilterServer(t)
+	client := NewClientWithURL("test-secret", srv.URL)
+
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 2, 3}
+	players, err := client.PlayersByIDs(context.Background(), ids, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, p := range players {
+		got = append(got, p.ID)
+	}
+	want := []int{1, 2, 3, 4, 5, 6, 7}
+	if len(got) != len(want) {
+		t.Fatalf("got ids %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got ids %v, want %v (chunk order, deduped)", got, want)
+		}
+	}
+	fs := filters()
+	if len(fs) != 3 {
+		t.Fatalf("want 3 chunk requests, got %d: %v", len(fs), fs)
+	}
+	for _, f := range fs {
+		if strings.Count(f, ",") > 2 {
+			t.Errorf("filter %q has more than 3 ids", f)
+		}
+	}
+}
+
+func TestItemsByIDs_KeepsBaseQuery(t *testing.T) {
+	var filter string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter = r.URL.Query().Get("filter")
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	client := NewClientWithURL("test-secret", srv.URL)
+
+	base := NewQuery().Eq("game.id", 5)
+	if _, err := client.GetTeamsByIDs(context.Background(), []int{9}, base); err != nil {
+		t.Fatal(err)
+	}
+	if filter != "game.id=5,id<={9}" {
+		t.Errorf("filter = %q, want base condition plus id set", filter)
+	}
+	if base.Filter() != "game.id=5" {
+		t.Errorf("base query modified: %q", base.Filter())
+	}
+}
+
+func TestItemsByIDs_EmptyMakesNoRequests(t *testing.T) {
+	srv, filters := idFilterServer(t)
+	client := NewClientWithURL("test-secret", srv.URL)
+	body, err := client.GetPlayersByIDs(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "[]" {
+		t.Errorf("body = %s, want []", body)
+	}
+	if n := len(filters()); n != 0 {
+		t.Errorf("want no requests, got %d", n)
+	}
+}
+
+func TestChunkIDs(t *testing.T) {
+	chunks := chunkIDs([]int{1, 2, 3, 4, 5}, 2)
+	if len(chunks) != 3 || len(chunks[0]) != 2 || len(chunks[2]) != 1 || chunks[2][0] != 5 {
+		t.Errorf("chunkIDs = %v", chunks)
+	}
+	if chunks := chunkIDs(nil, 2); len(chunks) != 0 {
+		t.Errorf("chunkIDs(nil) = %v", chunks)
+	}
+}
@@ -2,14 +2,13 @@ package atlas
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aaron/gamehub/internal/config"
@@ -28,58 +27,169 @@ type RateLimit struct {
 	ResetMs   int
 }
 
-// Client is an Atlas API client with reactive outbound rate limiting.
-// Throttles only after receiving 429 from Atlas; respects Retry-After.
+// Client is an Atlas API client with outbound rate limiting.
+// It holds a pool of API keys; each key is paced by its own token bucket synced
+// from X-RateLimit-* headers and on 429 additionally backs off for Retry-After.
 type Client struct {
-	baseURL         string
-	secret          string
-	httpClient      *http.Client
-	outMu           sync.Mutex
-	outBackoffUntil time.Time // don't send before this (zero = no backoff)
+	baseURL     string
+	keys        *keyPool
+	httpClient  *http.Client
+	pageWorkers atomic.Int32 // parallel page fetches in fetchAll
+	flights     flightGroup  // coalesces identical in-flight requests
+	validators  validatorCache
+	breaker     *breaker
+	sched       *scheduler // orders requests waiting for pacing by priority
 }
 
 // NewClient creates an Atlas API client.
-func NewClient(secret string) *Client {
-	return NewClientWithURL(secret, defaultBaseURL)
+func NewClient(secret string, opts ...Option) *Client {
+	return NewClientWithURL(secret, defaultBaseURL, opts...)
 }
 
 // NewClientWithURL creates a client with a custom base URL.
-func NewClientWithURL(secret, baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		secret:  secret,
-		httpClient: &http.Client{
-			Timeout: config.AtlasClientTimeout(),
-		},
-	}
+func NewClientWithURL(secret, baseURL string, opts ...Option) *Client {
+	return NewPooledClientWithURL([]string{secret}, baseURL, opts...)
 }
 
-// ErrRateLimited is returned when the API returns 429.
-type ErrRateLimited struct {
-	RetryAfterMs int
+// NewPooledClient creates a client that spreads requests over several API
+// keys: each request goes to the key with the most remaining quota, and a key
+// Atlas answers with 401 or 403 is not used again.
+func NewPooledClient(secrets []string, opts ...Option) *Client {
+	return NewPooledClientWithURL(secrets, defaultBaseURL, opts...)
 }
 
-func (e *ErrRateLimited) Error() string {
-	return fmt.Sprintf("rate limited: retry after %d ms", e.RetryAfterMs)
+// NewPooledClientWithURL creates a pooled client with a custom base URL.
+// Options add round-trippers or replace the HTTP client; see WithMiddleware,
+// WithTransport and WithHTTPClient.
+func NewPooledClientWithURL(secrets []string, baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: baseURL,
+		keys:    newKeyPool(secrets),
+		breaker: newBreaker(),
+		sched:   newScheduler(),
+	}
+	c.httpClient = c.newHTTPClient(opts)
+	c.SetPageConcurrency(config.AtlasPageConcurrency())
+	return c
 }
 
 // Get performs a GET request and returns body, rate limit info, and error.
-// On 429, returns ErrRateLimited with RetryAfterMs from the Retry-After header.
+// Transient failures are retried per the call's RetryPolicy (see WithRetryPolicy).
+// When retries are exhausted on 429, returns ErrRateLimited with RetryAfterMs
+// from the Retry-After header.
 func (c *Client) Get(ctx context.Context, path string) ([]byte, *RateLimit, error) {
-	if err := c.waitOutbound(ctx); err != nil {
-		return nil, nil, err
+	p := retryPolicyFrom(ctx)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		body, rl, err := c.getShared(ctx, path)
+		if err == nil {
+			return body, rl, nil
+		}
+		wait, ok := p.nextWait(ctx, attempt, err, start)
+		if !ok {
+			return nil, rl, err
+		}
+		metrics.AtlasRetries.Add(1)
+		if config.Debug() {
+			log.Printf("retry: %s attempt %d in %v: %v", path, attempt+1, wait, err)
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, rl, err
+		}
 	}
+}
+
+// getShared performs a single GET attempt, sharing the result with identical
+// concurrent attempts (same path, params and priority) instead of sending
+// another request. If the caller that sent the request gave up, waiters still
+// interested retry.
+func (c *Client) getShared(ctx context.Context, path string) ([]byte, *RateLimit, error) {
+	for {
+		body, rl, shared, err := c.flights.do(ctx, flightKey(ctx, path), func() ([]byte, *RateLimit, error) {
+			return c.get(ctx, path)
+		})
+		if shared && ctx.Err() == nil && isContextErr(err) {
+			continue
+		}
+		return body, rl, err
+	}
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// BreakerState returns the state of the client's circuit breaker.
+func (c *Client) BreakerState() BreakerState {
+	return c.breaker.current()
+}
+
+// get performs a single GET attempt with the key that has the most quota left.
+// The wait for a token or backoff is scheduled by the context's Priority; low
+// priorities are shed with ErrShed when the budget runs low. While the circuit
+// breaker is open it fails fast with ErrCircuitOpen. A key Atlas refuses is
+// disabled as described in keyPool.disable and the attempt moves on to the
+// next key.
+func (c *Client) get(ctx context.Context, path string) ([]byte, *RateLimit, error) {
+	for {
+		if err := c.sched.acquire(ctx, priorityFrom(ctx), c.keys.budget); err != nil {
+			return nil, nil, err
+		}
+		key, gen, err := c.pace(ctx)
+		c.sched.release()
+		if err != nil {
+			return nil, nil, err
+		}
+		start := time.Now()
+		body, rl, err := c.send(ctx, key, path)
+		c.breaker.record(time.Now(), gen, err, time.Since(start))
+		if status, ok := keyRejected(err); ok {
+			if c.keys.disable(key, status, path) {
+				continue
+			}
+		} else if err == nil {
+			key.accepted()
+		}
+		return body, rl, err
+	}
+}
+
+// pace picks a key and waits until it may send: past its backoff and holding a
+// token. The returned breaker generation must be passed to record; a request
+// that gave up while waiting locally never reached Atlas and is released.
+func (c *Client) pace(ctx context.Context) (*apiKey, uint64, error) {
+	key := c.keys.pick(time.Now())
+	if key == nil {
+		return nil, 0, ErrNoAPIKey
+	}
+	gen, err := c.breaker.allow(time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := key.waitBackoff(ctx); err != nil {
+		c.breaker.release(gen)
+		return nil, 0, err
+	}
+	if err := key.limiter.wait(ctx); err != nil {
+		c.breaker.release(gen)
+		return nil, 0, err
+	}
+	return key, gen, nil
+}
+
+// send performs the HTTP exchange for a single attempt with key.
+func (c *Client) send(ctx context.Context, key *apiKey, path string) ([]byte, *RateLimit, error) {
 	url := c.baseURL + path
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(withKey(ctx, key), http.MethodGet, url, nil)
 	if err != nil {
 		return nil, nil, err
 	}
-	req.Header.Set("Abios-Secret", c.secret)
 	req.Header.Set("Accept", "application/json")
+	cached := c.validators.apply(path, req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, transportError(err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -89,51 +199,41 @@ func (c *Client) Get(ctx context.Context, path string) ([]byte, *RateLimit, erro
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, transportError(err)
 	}
 
 	rl := parseRateLimit(resp.Header)
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		retryMs := parseRetryAfter(resp.Header.Get("Retry-After"))
-		c.setBackoff(retryMs)
-		metrics.Atlas429.Add(1)
-		metrics.RecordAtlasRetryAfter(retryMs)
-		return nil, rl, &ErrRateLimited{RetryAfterMs: retryMs}
+		return nil, rl, &ErrRateLimited{RetryAfterMs: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		metrics.AtlasNotModified.Add(1)
+		return cached.body, rl, nil
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, rl, fmt.Errorf("atlas API error: status %d: %s", resp.StatusCode, string(body))
+		return nil, rl, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
+	c.validators.store(path, resp.Header, body)
 	return body, rl, nil
 }
 
-// waitOutbound waits until any active backoff (from 429) has elapsed.
-func (c *Client) waitOutbound(ctx context.Context) error {
-	c.outMu.Lock()
-	until := c.outBackoffUntil
-	c.outMu.Unlock()
-	if until.IsZero() || time.Now().After(until) {
-		return nil
-	}
-	sleep := time.Until(until)
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case <-time.After(sleep):
-	}
-	return nil
+// backoffActive reports whether every usable key is backing off (from 429).
+func (c *Client) backoffActive() bool {
+	return c.keys.backingOff()
 }
 
-// setBackoff records that we received 429; next request will wait retryMs.
-func (c *Client) setBackoff(retryMs int) {
-	if retryMs <= 0 {
-		retryMs = int(config.AtlasOutboundMinBackoff().Milliseconds())
+// BackoffRemaining returns how long until some usable key may send again, or 0
+// if one can send now. Background jobs use it to stay out of Atlas's way.
+func (c *Client) BackoffRemaining() time.Duration {
+	k := c.keys.pick(time.Now())
+	if k == nil {
+		return 0
 	}
-	c.outMu.Lock()
-	c.outBackoffUntil = time.Now().Add(time.Duration(retryMs) * time.Millisecond)
-	c.outMu.Unlock()
+	return max(time.Until(k.backoff()), 0)
 }
 
 func buildPath(base string, params map[string]string) string {
@@ -167,44 +267,29 @@ func (c *Client) GetRosters(ctx context.Context, params map[string]string) ([]by
 	return c.Get(ctx, buildPath("/rosters", params))
 }
 
-// getAllPages fetches all pages from a paginated endpoint and returns merged results.
-// Follows Atlas pagination: take [0,50], skip [0,∞); stop when fewer than take
-// items or empty array.
-func (c *Client) getAllPages(ctx context.Context, path string, baseParams map[string]string) ([]byte, *RateLimit, error) {
-	var all []json.RawMessage
-	var lastRL *RateLimit
-	for skip := 0; ; skip += config.PageSize() {
-		params := make(map[string]string)
-		for k, v := range baseParams {
-			params[k] = v
-		}
-		params["skip"] = strconv.Itoa(skip)
-		params["take"] = strconv.Itoa(config.PageSize())
+// GetGames fetches /games with optional query params.
+func (c *Client) GetGames(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.Get(ctx, buildPath("/games", params))
+}
 
-		body, rl, err := c.Get(ctx, buildPath(path, params))
-		if err != nil {
-			return nil, rl, err
-		}
-		lastRL = rl
+// GetTournaments fetches /tournaments with optional query params.
+func (c *Client) GetTournaments(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.Get(ctx, buildPath("/tournaments", params))
+}
 
-		var page []json.RawMessage
-		if err := json.Unmarshal(body, &page); err != nil {
-			return nil, rl, err
-		}
-		if config.Debug() {
-			log.Printf("pagination: %s skip=%d -> %d items", path, skip, len(page))
-		}
-		all = append(all, page...)
-		// Stop when fewer than take (includes empty array)
-		if len(page) < config.PageSize() {
-			break
-		}
-	}
-	out, err := json.Marshal(all)
-	if err != nil {
-		return nil, lastRL, err
-	}
-	return out, lastRL, nil
+// GetStages fetches /stages with optional query params.
+func (c *Client) GetStages(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.Get(ctx, buildPath("/stages", params))
+}
+
+// GetSubstages fetches /substages with optional query params.
+func (c *Client) GetSubstages(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.Get(ctx, buildPath("/substages", params))
+}
+
+// GetMatches fetches /matches with optional query params.
+func (c *Client) GetMatches(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.Get(ctx, buildPath("/matches", params))
 }
 
 // GetSeriesAll fetches all series matching params, paginating until complete.
@@ -227,22 +312,83 @@ func (c *Client) GetTeamsAll(ctx context.Context, params map[string]string) ([]b
 	return c.getAllPages(ctx, "/teams", params)
 }
 
+// GetGamesAll fetches all games matching params, paginating until complete.
+func (c *Client) GetGamesAll(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.getAllPages(ctx, "/games", params)
+}
+
+// GetTournamentsAll fetches all tournaments matching params, paginating until complete.
+func (c *Client) GetTournamentsAll(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.getAllPages(ctx, "/tournaments", params)
+}
+
+// GetStagesAll fetches all stages matching params, paginating until complete.
+func (c *Client) GetStagesAll(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.getAllPages(ctx, "/stages", params)
+}
+
+// GetSubstagesAll fetches all substages matching params, paginating until complete.
+func (c *Client) GetSubstagesAll(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.getAllPages(ctx, "/substages", params)
+}
+
+// GetMatchesAll fetches all matches matching params, paginating until complete.
+func (c *Client) GetMatchesAll(ctx context.Context, params map[string]string) ([]byte, *RateLimit, error) {
+	return c.getAllPages(ctx, "/matches", params)
+}
+
+// SeriesAll fetches all series matching params, decoded into typed models.
+func (c *Client) SeriesAll(ctx context.Context, params map[string]string) ([]Series, PageInfo, error) {
+	return fetchAll[Series](ctx, c, "/series", params)
+}
+
+// RostersAll fetches all rosters matching params, decoded into typed models.
+func (c *Client) RostersAll(ctx context.Context, params map[string]string) ([]Roster, PageInfo, error) {
+	return fetchAll[Roster](ctx, c, "/rosters", params)
+}
+
+// PlayersAll fetches all players matching params, decoded into typed models.
+func (c *Client) PlayersAll(ctx context.Context, params map[string]string) ([]Player, PageInfo, error) {
+	return fetchAll[Player](ctx, c, "/players", params)
+}
+
+// TeamsAll fetches all teams matching params, decoded into typed models.
+func (c *Client) TeamsAll(ctx context.Context, params map[string]string) ([]Team, PageInfo, error) {
+	return fetchAll[Team](ctx, c, "/teams", params)
+}
+
+// GamesAll fetches all games matching params, decoded into typed models.
+func (c *Client) GamesAll(ctx context.Context, params map[string]string) ([]Game, PageInfo, error) {
+	return fetchAll[Game](ctx, c, "/games", params)
+}
+
+// TournamentsAll fetches all tournaments matching params, decoded into typed models.
+func (c *Client) TournamentsAll(ctx context.Context, params map[string]string) ([]Tournament, PageInfo, error) {
+	return fetchAll[Tournament](ctx, c, "/tournaments", params)
+}
+
+// StagesAll fetches all stages matching params, decoded into typed models.
+func (c *Client) StagesAll(ctx context.Context, params map[string]string) ([]Stage, PageInfo, error) {
+	return fetchAll[Stage](ctx, c, "/stages", params)
+}
+
+// SubstagesAll fetches all substages matching params, decoded into typed models.
+func (c *Client) SubstagesAll(ctx context.Context, params map[string]string) ([]Substage, PageInfo, error) {
+	return fetchAll[Substage](ctx, c, "/substages", params)
+}
+
+// MatchesAll fetches all matches matching params, decoded into typed models.
+func (c *Client) MatchesAll(ctx context.Context, params map[string]string) ([]Match, PageInfo, error) {
+	return fetchAll[Match](ctx, c, "/matches", params)
+}
+
 // FilterIDIn formats filter=id<={ids} for the Atlas API.
 // IDs are comma-separated in curly braces, e.g. filter=id<={1,2,3}.
 func FilterIDIn(ids []int) string {
 	if len(ids) == 0 {
 		return ""
 	}
-	b := make([]byte, 0, 32)
-	b = append(b, "id<={"...)
-	for i, id := range ids {
-		if i > 0 {
-			b = append(b, ',')
-		}
-		b = strconv.AppendInt(b, int64(id), 10)
-	}
-	b = append(b, '}')
-	return string(b)
+	return NewQuery().InInts("id", ids).Filter()
 }
 
 func parseRateLimit(h http.Header) *RateLimit {
@@ -3,6 +3,7 @@ package atlas
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -46,6 +47,7 @@ func TestGetAllPages_ContinuesPastFullPage(t *testing.T) {
 	defer server.Close()
 
 	client := NewClientWithURL("test-secret", server.URL)
+	client.SetPageConcurrency(1) // sequential: exactly one request per page
 	body, _, err := client.GetPlayersAll(context.Background(), map[string]string{"filter": "id<={1}"})
 	if err != nil {
 		t.Fatal(err)
@@ -119,3 +121,117 @@ func TestFilterIDIn(t *testing.T) {
 		}
 	}
 }
+
+func TestSeriesAll_DecodesTypedModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/series" {
+			t.Errorf("path: want /series, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[
+			{"id":1,"title":"A vs B","lifecycle":"live","start":"2026-01-02T15:04:05Z",
+			 "tournament":{"id":7},"participants":[{"seed":1,"roster":{"id":100}},{"seed":2,"roster":{"id":101}}]}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	series, _, err := client.SeriesAll(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("want 1 series, got %d", len(series))
+	}
+	s := series[0]
+	if s.ID != 1 || s.Title != "A vs B" || s.Lifecycle != "live" || s.Tournament.ID != 7 {
+		t.Errorf("unexpected series: %+v", s)
+	}
+	if s.Start == nil || s.Start.Year() != 2026 {
+		t.Errorf("start: want 2026-01-02, got %v", s.Start)
+	}
+	if len(s.Participants) != 2 || s.Participants[1].Roster.ID != 101 {
+		t.Errorf("participants: got %+v", s.Participants)
+	}
+}
+
+func TestRostersAll_EmptyIsNotNil(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	rosters, _, err := client.RostersAll(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rosters == nil || len(rosters) != 0 {
+		t.Errorf("want empty non-nil slice, got %#v", rosters)
+	}
+	body, _, err := client.GetRostersAll(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "[]" {
+		t.Errorf("raw body: want [], got %s", body)
+	}
+}
+
+func TestGet_ErrorKinds(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusServiceUnavailable, ErrUnavailable},
+		{http.StatusGatewayTimeout, ErrTimeout},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		client := NewClientWithURL("test-secret", server.URL)
+		_, _, err := client.Get(WithRetryPolicy(context.Background(), NoRetry), "/series")
+		server.Close()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: want %v, got %v", tt.status, tt.want, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+			t.Errorf("status %d: want *APIError, got %v", tt.status, err)
+		}
+	}
+}
+
+func TestGet_TransportErrorKinds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+	client := NewClientWithURL("test-secret", url)
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	if _, _, err := client.Get(ctx, "/series"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("connection refused: want ErrUnavailable, got %v", err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	client = NewClientWithURL("test-secret", slow.URL, WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	if _, _, err := client.Get(ctx, "/series"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("client timeout: want ErrTimeout, got %v", err)
+	}
+}
+
+func TestSeriesAll_DecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"not":"an array"}`))
+	}))
+	defer server.Close()
+	client := NewClientWithURL("test-secret", server.URL)
+	if _, _, err := client.SeriesAll(context.Background(), nil); !errors.Is(err, ErrDecode) {
+		t.Errorf("want ErrDecode, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package atlas
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// flightGroup merges concurrent identical requests into one upstream call.
+// Results are shared by all waiters; the body must be treated as read-only.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	body []byte
+	rl   *RateLimit
+	err  error
+}
+
+// do runs fn once per key at a time. Callers arriving while a call for key is
+// in flight wait for its result instead (shared = true). A waiter stops
+// waiting when its own ctx is done.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]byte, *RateLimit, error)) (body []byte, rl *RateLimit, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		metrics.AtlasCoalesced.Add(1)
+		select {
+		case <-ctx.Done():
+			return nil, nil, true, ctx.Err()
+		case <-f.done:
+			return f.body, f.rl, true, f.err
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	f.body, f.rl, f.err = fn()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(f.done)
+	return f.body, f.rl, false, f.err
+}
+
+// flightKey is the coalescing key of a GET: its priority and path. A flight
+// is scheduled (and shed) at the priority of the caller that sends it, so
+// callers only share flights of their own priority; an interactive request
+// never waits behind a queued or shed background one.
+func flightKey(ctx context.Context, path string) string {
+	return priorityFrom(ctx).String() + " " + path
+}
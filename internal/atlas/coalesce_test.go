@@ -0,0 +1,163 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+func TestGet_CoalescesIdenticalRequests(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	before := metrics.AtlasCoalesced.Load()
+
+	const n = 5
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Go(func() {
+			body, _, err := client.GetSeries(context.Background(), map[string]string{"filter": "lifecycle=live"})
+			bodies[i], errs[i] = string(body), err
+		})
+	}
+	// Wait until all but the leader are waiting on the shared flight.
+	deadline := time.Now().Add(2 * time.Second)
+	for metrics.AtlasCoalesced.Load()-before < n-1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("upstream requests: want 1, got %d", got)
+	}
+	for i := range n {
+		if errs[i] != nil || bodies[i] != `[{"id":1}]` {
+			t.Errorf("caller %d: body %q, err %v", i, bodies[i], errs[i])
+		}
+	}
+	if got := metrics.AtlasCoalesced.Load() - before; got != n-1 {
+		t.Errorf("coalesced: want %d, got %d", n-1, got)
+	}
+}
+
+func TestGet_WaiterRetriesWhenLeaderCancelled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-r.Context().Done() // leader's request hangs until it gives up
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	leaderCtx, cancelLeader := context.WithCancel(WithRetryPolicy(context.Background(), NoRetry))
+	leaderDone := make(chan error, 1)
+	go func() {
+		_, _, err := client.Get(leaderCtx, "/series")
+		leaderDone <- err
+	}()
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	before := metrics.AtlasCoalesced.Load()
+	waiterDone := make(chan error, 1)
+	var body []byte
+	go func() {
+		var err error
+		body, _, err = client.Get(WithRetryPolicy(context.Background(), NoRetry), "/series")
+		waiterDone <- err
+	}()
+	for metrics.AtlasCoalesced.Load() == before {
+		time.Sleep(time.Millisecond)
+	}
+	cancelLeader()
+
+	if err := <-leaderDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader: want context.Canceled, got %v", err)
+	}
+	select {
+	case err := <-waiterDone:
+		if err != nil || string(body) != "ok" {
+			t.Errorf("waiter: want ok after retry, got %q, %v", body, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiter did not return")
+	}
+}
+
+func TestFlightGroup_WaiterStopsOnOwnContext(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go func() {
+		_, _, _, _ = g.do(context.Background(), "k", func() ([]byte, *RateLimit, error) {
+			close(started)
+			<-release
+			return nil, nil, nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, shared, err := g.do(ctx, "k", func() ([]byte, *RateLimit, error) {
+		t.Error("waiter must not run fn while a flight is active")
+		return nil, nil, nil
+	})
+	if !shared || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want shared DeadlineExceeded, got shared=%v err=%v", shared, err)
+	}
+}
+
+func TestGet_CoalescesOnlyWithinPriority(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			<-release // the warm request hangs
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClientWithURL("test-secret", server.URL)
+	warmDone := make(chan error, 1)
+	go func() {
+		_, _, err := client.Get(WithPriority(context.Background(), PriorityWarm), "/series")
+		warmDone <- err
+	}()
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if body, _, err := client.Get(ctx, "/series"); err != nil || string(body) != "ok" {
+		t.Fatalf("interactive call behind a warm flight: %q, %v", body, err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("upstream requests: want 2 (one per priority), got %d", n)
+	}
+}
@@ -0,0 +1,65 @@
+package atlas
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/config"
+)
+
+// notModifiedCost is the fraction of an outbound token a 304 is charged.
+// The token is taken before sending; the rest is refunded on 304.
+const notModifiedCost = 0.25
+
+// validatorCache remembers ETag/Last-Modified and the body per request path,
+// so repeated requests can be sent conditionally and a 304 served locally.
+type validatorCache struct {
+	mu      sync.Mutex
+	entries map[string]*validated
+}
+
+type validated struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// apply adds If-None-Match/If-Modified-Since to req if path has validators.
+// Returns the stored entry to serve on 304, or nil.
+func (v *validatorCache) apply(path string, req *http.Request) *validated {
+	v.mu.Lock()
+	e := v.entries[path]
+	v.mu.Unlock()
+	if e == nil {
+		return nil
+	}
+	if e.etag != "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+	return e
+}
+
+// store records the validators of a 2xx response. Responses without
+// validators drop any previous entry for path.
+func (v *validatorCache) store(path string, h http.Header, body []byte) {
+	etag, lastModified := h.Get("ETag"), h.Get("Last-Modified")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if etag == "" && lastModified == "" {
+		delete(v.entries, path)
+		return
+	}
+	if v.entries == nil {
+		v.entries = make(map[string]*validated)
+	}
+	if _, ok := v.entries[path]; !ok && len(v.entries) >= config.AtlasConditionalCacheSize() {
+		for k := range v.entries { // evict an arbitrary entry
+			delete(v.entries, k)
+			break
+		}
+	}
+	v.entries[path] = &validated{etag: etag, lastModified: lastModified, body: body}
+}
@@ -0,0 +1,77 @@
+package atlas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+func TestGet_ConditionalRequestServesStoredBodyOn304(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n > 1 {
+			if got := r.Header.Get("If-None-Match"); got != `"v1"` {
+				t.Errorf("request %d: If-None-Match = %q, want \"v1\"", n, got)
+			}
+			if got := r.Header.Get("If-Modified-Since"); got != "Wed, 01 Jan 2026 00:00:00 GMT" {
+				t.Errorf("request %d: If-Modified-Since = %q", n, got)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 01 Jan 2026 00:00:00 GMT")
+		_, _ = w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	before := metrics.AtlasNotModified.Load()
+	for i := 0; i < 2; i++ {
+		body, _, err := client.Get(context.Background(), "/series")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != `[{"id":1}]` {
+			t.Errorf("call %d: body %s", i+1, body)
+		}
+	}
+	if got := metrics.AtlasNotModified.Load() - before; got != 1 {
+		t.Errorf("not modified: want 1, got %d", got)
+	}
+}
+
+func TestGet_NoConditionalHeadersWithoutValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Error("conditional headers sent without stored validators")
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOutboundLimiter_RefundCappedAtBurst(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	l.sync(&RateLimit{Limit: 1, Burst: 1, Remaining: 1}, now)
+	l.reserve(now)
+	l.refund(1 - notModifiedCost)
+	l.refund(1 - notModifiedCost)
+	if l.tokens != 1 {
+		t.Errorf("tokens after refunds = %v, want capped at burst 1", l.tokens)
+	}
+}
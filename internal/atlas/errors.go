@@ -0,0 +1,81 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Error kinds. Every error returned by the Client for an Atlas failure matches
+// one of these with errors.Is; use errors.As with *APIError, *ErrRateLimited
+// or *ErrCircuitOpen for details.
+var (
+	ErrNotFound     = errors.New("atlas: not found")            // 404
+	ErrUnauthorized = errors.New("atlas: unauthorized")         // 401, 403, no usable API key
+	ErrBadRequest   = errors.New("atlas: bad request")          // 400, 422: the query was rejected
+	ErrUnavailable  = errors.New("atlas: upstream unavailable") // 5xx, connection failures, circuit open
+	ErrTimeout      = errors.New("atlas: timeout")              // 504, request timed out
+	ErrDecode       = errors.New("atlas: decode failed")        // response body is not what we expect
+)
+
+// ErrRateLimited is returned when the API returns 429.
+type ErrRateLimited struct {
+	RetryAfterMs int
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("rate limited: retry after %d ms", e.RetryAfterMs)
+}
+
+// APIError is returned for non-2xx responses other than 429. Body is the raw
+// Atlas response for logging; it must not be passed on to GameHub clients.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("atlas API error: status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is matches the error kind for the status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrTimeout:
+		return e.StatusCode == http.StatusGatewayTimeout
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError && e.StatusCode != http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// Is reports ErrCircuitOpen as ErrUnavailable.
+func (e *ErrCircuitOpen) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// transportError classifies a failed HTTP exchange as ErrTimeout or
+// ErrUnavailable, keeping the cause. Cancellation by the caller is returned as is.
+func transportError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
+
+// decodeError wraps a JSON decode failure for path as ErrDecode.
+func decodeError(path string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrDecode, path, err)
+}
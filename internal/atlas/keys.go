@@ -0,0 +1,258 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// ErrNoAPIKey is returned when the pool has no API key to send with.
+// It matches ErrUnauthorized.
+var ErrNoAPIKey = fmt.Errorf("%w: no usable API key", ErrUnauthorized)
+
+// apiKey is one Atlas API key with its own quota: a token bucket synced from
+// the responses it gets and a backoff from its own 429s.
+type apiKey struct {
+	secret   string
+	label    string // shown in logs and /stats instead of the secret
+	limiter  outboundLimiter
+	stats    *metrics.AtlasKeyStats
+	disabled atomic.Int64 // UnixNano until which Atlas rejected the key (0 = usable)
+
+	mu           sync.Mutex
+	backoffUntil time.Time       // don't send before this (zero = no backoff)
+	forbidden    map[string]bool // resources answered 403 since a request was last accepted
+}
+
+// forbiddenResources is how many different resources must refuse a key with
+// 403, with nothing accepted in between, before it is disabled. A single 403
+// usually means the key's plan does not include that resource.
+const forbiddenResources = 2
+
+// keyPool routes each request to the usable key with the most quota left.
+type keyPool struct {
+	keys       []*apiKey
+	disableFor time.Duration // how long a rejected key is left out
+	next       atomic.Uint32 // rotates the scan start so ties are spread over keys
+	mu         sync.Mutex    // serializes disabling, so the last usable key stays
+}
+
+func newKeyPool(secrets []string) *keyPool {
+	p := &keyPool{keys: make([]*apiKey, len(secrets)), disableFor: config.AtlasKeyDisableFor()}
+	for i, s := range secrets {
+		label := fmt.Sprintf("key%d", i+1)
+		p.keys[i] = &apiKey{secret: s, label: label, stats: metrics.AtlasKey(label)}
+		p.keys[i].stats.Disabled.Store(false)
+	}
+	return p
+}
+
+// pick returns the key to send the next request with, or nil if all keys are
+// disabled. Keys not backing off win over keys that are; among those, the key
+// with the most tokens wins (a key not yet synced counts as full). If every key
+// is backing off, the one whose backoff ends first is returned.
+func (p *keyPool) pick(now time.Time) *apiKey {
+	n := len(p.keys)
+	start := int(p.next.Add(1))
+	var best *apiKey
+	var bestUntil time.Time
+	var bestTokens float64
+	for i := range n {
+		k := p.keys[(start+i)%n]
+		if k.isDisabled(now) {
+			continue
+		}
+		until := k.backoff()
+		if !now.Before(until) {
+			until = time.Time{}
+		}
+		tokens := k.limiter.available(now)
+		switch {
+		case best == nil:
+		case until.IsZero() != bestUntil.IsZero():
+			if !until.IsZero() {
+				continue
+			}
+		case !until.IsZero():
+			if !until.Before(bestUntil) {
+				continue
+			}
+		case tokens <= bestTokens:
+			continue
+		}
+		best, bestUntil, bestTokens = k, until, tokens
+	}
+	return best
+}
+
+// backingOff reports whether no usable key can send right now.
+func (p *keyPool) backingOff() bool {
+	k := p.pick(time.Now())
+	return k != nil && time.Now().Before(k.backoff())
+}
+
+// budget returns the share of pooled quota left, from 0 to 1: tokens over
+// burst summed over usable keys. Keys not yet synced count as full, keys
+// backing off as empty.
+func (p *keyPool) budget() float64 {
+	now := time.Now()
+	var left, total float64
+	for _, k := range p.keys {
+		if k.isDisabled(now) {
+			continue
+		}
+		total++
+		if now.Before(k.backoff()) {
+			continue
+		}
+		left += k.limiter.fill(now)
+	}
+	if total == 0 {
+		return 0
+	}
+	return left / total
+}
+
+// disable stops using k for disableFor after Atlas rejected it on path and
+// reports whether the attempt should move on to another key. A 401 disables k;
+// a 403 only once forbiddenResources resources refused it. The last usable key
+// is never disabled: the caller gets the error for that one call. A disabled
+// key is tried again once the time is up.
+func (p *keyPool) disable(k *apiKey, status int, path string) bool {
+	if status == http.StatusForbidden && k.forbid(resourceOf(path)) < forbiddenResources {
+		return false
+	}
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if k.isDisabled(now) {
+		return true // another request disabled it meanwhile
+	}
+	usable := false
+	for _, other := range p.keys {
+		if other != k && !other.isDisabled(now) {
+			usable = true
+			break
+		}
+	}
+	if !usable {
+		log.Printf("atlas: API key %q got status %d; keeping it as the last usable key", k.label, status)
+		return false
+	}
+	k.disabled.Store(now.Add(p.disableFor).UnixNano())
+	log.Printf("atlas: disabling API key %q for %v after status %d", k.label, p.disableFor, status)
+	k.stats.Disabled.Store(true)
+	p.recordRemaining()
+	return true
+}
+
+// forbid records that resource refused k with 403 and returns how many
+// different resources have since k was last accepted.
+func (k *apiKey) forbid(resource string) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.forbidden == nil {
+		k.forbidden = make(map[string]bool)
+	}
+	k.forbidden[resource] = true
+	return len(k.forbidden)
+}
+
+// accepted records that Atlas accepted k.
+func (k *apiKey) accepted() {
+	k.mu.Lock()
+	k.forbidden = nil
+	k.mu.Unlock()
+}
+
+// resourceOf returns the top-level resource of a request path:
+// "/matches/5?x=1" -> "matches".
+func resourceOf(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// isDisabled reports whether k is still left out at now; a key whose time is
+// up is enabled again.
+func (k *apiKey) isDisabled(now time.Time) bool {
+	until := k.disabled.Load()
+	if until == 0 {
+		return false
+	}
+	if now.UnixNano() < until {
+		return true
+	}
+	if k.disabled.CompareAndSwap(until, 0) {
+		log.Printf("atlas: re-enabling API key %q", k.label)
+		k.stats.Disabled.Store(false)
+	}
+	return false
+}
+
+// observe syncs k's limiter from a response and updates the quota metrics.
+func (p *keyPool) observe(k *apiKey, rl *RateLimit) {
+	k.limiter.sync(rl, time.Now())
+	if rl == nil || rl.Limit <= 0 {
+		return
+	}
+	k.stats.Remaining.Store(int64(rl.Remaining))
+	p.recordRemaining()
+}
+
+// recordRemaining publishes the pooled remaining quota of all usable keys.
+func (p *keyPool) recordRemaining() {
+	now := time.Now()
+	total := 0
+	for _, k := range p.keys {
+		if r := k.stats.Remaining.Load(); r > 0 && !k.isDisabled(now) {
+			total += int(r)
+		}
+	}
+	metrics.RecordAtlasRemaining(total)
+}
+
+// waitBackoff waits until k's backoff (from 429) has elapsed.
+func (k *apiKey) waitBackoff(ctx context.Context) error {
+	until := k.backoff()
+	if until.IsZero() || time.Now().After(until) {
+		return nil
+	}
+	return sleepCtx(ctx, time.Until(until))
+}
+
+func (k *apiKey) backoff() time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.backoffUntil
+}
+
+// setBackoff records that k received 429; its next request waits retryMs.
+func (k *apiKey) setBackoff(retryMs int) {
+	if retryMs <= 0 {
+		retryMs = int(config.AtlasOutboundMinBackoff().Milliseconds())
+	}
+	k.mu.Lock()
+	k.backoffUntil = time.Now().Add(time.Duration(retryMs) * time.Millisecond)
+	k.mu.Unlock()
+}
+
+// keyRejected reports the status if err means Atlas refused the key itself.
+func keyRejected(err error) (int, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return apiErr.StatusCode, true
+	}
+	return 0, false
+}
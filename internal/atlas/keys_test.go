@@ -0,0 +1,212 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// keyServer answers per Abios-Secret and counts requests per key.
+type keyServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits map[string]int
+}
+
+func newKeyServer(t *testing.T, handle func(w http.ResponseWriter, key string)) *keyServer {
+	ks := &keyServer{hits: make(map[string]int)}
+	ks.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Abios-Secret")
+		ks.mu.Lock()
+		ks.hits[key]++
+		ks.mu.Unlock()
+		handle(w, key)
+	}))
+	t.Cleanup(ks.Close)
+	return ks
+}
+
+func (ks *keyServer) count(key string) int {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	return ks.hits[key]
+}
+
+func TestKeyPool_RoutesToKeyWithMostQuota(t *testing.T) {
+	ks := newKeyServer(t, func(w http.ResponseWriter, key string) {
+		w.Header().Set("X-RateLimit-Limit", "1")
+		w.Header().Set("X-RateLimit-Burst", "100")
+		if key == "small" {
+			w.Header().Set("X-RateLimit-Remaining", "2")
+		} else {
+			w.Header().Set("X-RateLimit-Remaining", "80")
+		}
+		_, _ = w.Write([]byte(`[]`))
+	})
+
+	client := NewPooledClientWithURL([]string{"small", "large"}, ks.URL)
+	for i := 0; i < 10; i++ {
+		if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unsynced keys are tried first; after that every request goes to "large".
+	if got := ks.count("small"); got != 1 {
+		t.Errorf("small key: %d requests, want 1", got)
+	}
+	if got := ks.count("large"); got != 9 {
+		t.Errorf("large key: %d requests, want 9", got)
+	}
+}
+
+func TestKeyPool_DisablesRejectedKey(t *testing.T) {
+	ks := newKeyServer(t, func(w http.ResponseWriter, key string) {
+		if key == "revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	})
+
+	client := NewPooledClientWithURL([]string{"revoked", "good"}, ks.URL)
+	for i := 0; i < 5; i++ {
+		if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	if got := ks.count("revoked"); got > 1 {
+		t.Errorf("revoked key used %d times after 401, want at most 1", got)
+	}
+	if !client.keys.keys[0].stats.Disabled.Load() {
+		t.Error("revoked key not reported as disabled")
+	}
+}
+
+func TestKeyPool_LastKeyIsNeverDisabled(t *testing.T) {
+	ks := newKeyServer(t, func(w http.ResponseWriter, key string) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	client := NewPooledClientWithURL([]string{"a", "b"}, ks.URL)
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	for i := range 2 {
+		_, _, err := client.Get(ctx, "/series")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("call %d: want 401 APIError, got %v", i+1, err)
+		}
+	}
+	if a, b := ks.count("a"), ks.count("b"); a+b != 3 || min(a, b) != 1 {
+		t.Errorf("hits a=%d b=%d, want the first call on both keys, the second on the kept one", a, b)
+	}
+	a, b := client.keys.keys[0].stats.Disabled.Load(), client.keys.keys[1].stats.Disabled.Load()
+	if a == b {
+		t.Errorf("disabled a=%v b=%v, want exactly one", a, b)
+	}
+}
+
+func TestKeyPool_ForbiddenResourceKeepsSingleKey(t *testing.T) {
+	var matches atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/matches" {
+			matches.Store(true)
+			w.WriteHeader(http.StatusForbidden) // not in the key's plan
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	client := NewClientWithURL("only", srv.URL)
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	var apiErr *APIError
+	if _, _, err := client.Get(ctx, "/matches"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("/matches: want 403 APIError, got %v", err)
+	}
+	if _, _, err := client.Get(ctx, "/series?filter=lifecycle%3Dlive"); err != nil {
+		t.Fatalf("/series after a 403 elsewhere: %v", err)
+	}
+	if !matches.Load() || client.keys.keys[0].stats.Disabled.Load() {
+		t.Error("key disabled by a single 403")
+	}
+}
+
+func TestKeyPool_DisableOnForbidden(t *testing.T) {
+	p := newKeyPool([]string{"a", "b"})
+	a, b := p.keys[0], p.keys[1]
+	if p.disable(a, http.StatusForbidden, "/matches?skip=0") || p.disable(a, http.StatusForbidden, "/matches/5") {
+		t.Fatal("disabled after 403s on one resource")
+	}
+	a.accepted()
+	if p.disable(a, http.StatusForbidden, "/stages") {
+		t.Fatal("403s before an accepted request still counted")
+	}
+	if !p.disable(a, http.StatusForbidden, "/teams") {
+		t.Fatal("not disabled after 403s on two resources")
+	}
+	if !a.isDisabled(time.Now()) || !a.stats.Disabled.Load() {
+		t.Error("a not reported as disabled")
+	}
+	if p.disable(b, http.StatusUnauthorized, "/series") || b.isDisabled(time.Now()) {
+		t.Error("last usable key disabled")
+	}
+}
+
+func TestKeyPool_DisabledKeyReturnsAfterCooldown(t *testing.T) {
+	p := newKeyPool([]string{"a", "b"})
+	p.disableFor = time.Minute
+	a := p.keys[0]
+	if !p.disable(a, http.StatusUnauthorized, "/series") {
+		t.Fatal("a not disabled")
+	}
+	if !a.isDisabled(time.Now()) {
+		t.Fatal("a usable during its cooldown")
+	}
+	if a.isDisabled(time.Now().Add(time.Minute)) || a.stats.Disabled.Load() {
+		t.Error("a still disabled after its cooldown")
+	}
+	if p.pick(time.Now()) == nil || p.budget() == 0 {
+		t.Error("pool has no usable key after the cooldown")
+	}
+}
+
+func TestKeyPool_RateLimitedKeyDoesNotBlockOthers(t *testing.T) {
+	ks := newKeyServer(t, func(w http.ResponseWriter, key string) {
+		if key == "limited" {
+			w.Header().Set("Retry-After", "60000")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	})
+
+	client := NewPooledClientWithURL([]string{"limited", "free"}, ks.URL)
+	client.keys.keys[0].setBackoff(60000)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("requests waited %v for another key's backoff", elapsed)
+	}
+	if got := ks.count("limited"); got != 0 {
+		t.Errorf("key in backoff used %d times", got)
+	}
+	if client.backoffActive() {
+		t.Error("backoffActive with a free key in the pool")
+	}
+	if d := client.BackoffRemaining(); d != 0 {
+		t.Errorf("BackoffRemaining = %v with a free key in the pool", d)
+	}
+	client.keys.keys[1].setBackoff(30000)
+	if d := client.BackoffRemaining(); d < 29*time.Second || d > 30*time.Second {
+		t.Errorf("BackoffRemaining = %v, want the shorter backoff (~30s)", d)
+	}
+}
@@ -0,0 +1,135 @@
+package atlas
+
+import "time"
+
+// Typed models for Atlas v3 resources. Only fields GameHub and its downstream
+// services rely on are modelled; unknown fields are ignored on decode. Use the
+// raw []byte methods (GetSeriesAll etc.) when the full payload is needed.
+
+// Ref is a reference to another resource by ID, e.g. {"id": 1}.
+type Ref struct {
+	ID int `json:"id"`
+}
+
+// Image is a resource image (logo, photo).
+type Image struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Series is a set of matches between participants, e.g. a best-of-three.
+type Series struct {
+	ID           int           `json:"id"`
+	Title        string        `json:"title"`
+	Start        *time.Time    `json:"start"`
+	End          *time.Time    `json:"end"`
+	Lifecycle    string        `json:"lifecycle"`
+	Game         Ref           `json:"game"`
+	Tournament   Ref           `json:"tournament"`
+	Substage     Ref           `json:"substage"`
+	Format       Format        `json:"format"`
+	Participants []Participant `json:"participants"`
+	Matches      []Ref         `json:"matches"`
+}
+
+// Format describes the series format, e.g. best of 3.
+type Format struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	BestOf int    `json:"best_of"`
+}
+
+// Participant is a roster taking part in a series.
+type Participant struct {
+	Seed    int  `json:"seed"`
+	Score   int  `json:"score"`
+	Forfeit bool `json:"forfeit"`
+	Winner  bool `json:"winner"`
+	Roster  Ref  `json:"roster"`
+}
+
+// Roster is a team's line-up for a given game.
+type Roster struct {
+	ID     int    `json:"id"`
+	Game   Ref    `json:"game"`
+	Team   Ref    `json:"team"`
+	LineUp LineUp `json:"line_up"`
+}
+
+// LineUp lists the players of a roster.
+type LineUp struct {
+	ID      int   `json:"id"`
+	Players []Ref `json:"players"`
+}
+
+// Team is an esports organisation's team for a game.
+type Team struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Abbreviation string  `json:"abbreviation"`
+	Game         Ref     `json:"game"`
+	Images       []Image `json:"images"`
+}
+
+// Player is an individual competitor.
+type Player struct {
+	ID        int     `json:"id"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	NickName  string  `json:"nick_name"`
+	Game      Ref     `json:"game"`
+	Images    []Image `json:"images"`
+}
+
+// Game is a title covered by Atlas, e.g. CS2 or Dota 2.
+type Game struct {
+	ID        int     `json:"id"`
+	Title     string  `json:"title"`
+	LongTitle string  `json:"long_title"`
+	Color     string  `json:"color"`
+	Images    []Image `json:"images"`
+}
+
+// Tournament is a competition containing stages and series.
+type Tournament struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Abbreviation string     `json:"abbreviation"`
+	Start        *time.Time `json:"start"`
+	End          *time.Time `json:"end"`
+	Tier         int        `json:"tier"`
+	Game         Ref        `json:"game"`
+	Images       []Image    `json:"images"`
+	Stages       []Ref      `json:"stages"`
+}
+
+// Stage is a phase of a tournament, e.g. group stage or playoffs.
+type Stage struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	Start      *time.Time `json:"start"`
+	End        *time.Time `json:"end"`
+	Tournament Ref        `json:"tournament"`
+	Substages  []Ref      `json:"substages"`
+}
+
+// Substage is a part of a stage, e.g. one group or one bracket.
+type Substage struct {
+	ID     int        `json:"id"`
+	Title  string     `json:"title"`
+	Start  *time.Time `json:"start"`
+	End    *time.Time `json:"end"`
+	Stage  Ref        `json:"stage"`
+	Series []Ref      `json:"series"`
+}
+
+// Match is a single game played within a series, e.g. one map.
+type Match struct {
+	ID           int           `json:"id"`
+	Order        int           `json:"order"`
+	Lifecycle    string        `json:"lifecycle"`
+	Series       Ref           `json:"series"`
+	Game         Ref           `json:"game"`
+	Participants []Participant `json:"participants"`
+}
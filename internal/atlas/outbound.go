@@ -0,0 +1,124 @@
+package atlas
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// outboundLimiter is a token bucket shared by all requests from a Client.
+// It starts unpaced and is re-synced from Atlas X-RateLimit-* headers on every
+// response, so requests are spread out before Atlas has to answer with 429.
+type outboundLimiter struct {
+	mu     sync.Mutex
+	synced bool      // false until the first response with rate limit headers
+	rate   float64   // tokens per second (X-RateLimit-Limit)
+	burst  float64   // bucket capacity (X-RateLimit-Burst)
+	tokens float64   // may go negative when Atlas asks us to wait for a reset
+	last   time.Time // last refill
+}
+
+// wait blocks until a token is available or ctx is done.
+func (l *outboundLimiter) wait(ctx context.Context) error {
+	d := l.reserve(time.Now())
+	if d <= 0 {
+		return nil
+	}
+	metrics.AtlasPaced.Add(1)
+	return sleepCtx(ctx, d)
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it (0 = send now). Waiting callers queue up in reservation order.
+func (l *outboundLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.synced {
+		return 0
+	}
+	l.refillLocked(now)
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// sync updates the bucket from response headers. Remaining is authoritative
+// only downwards: responses to concurrent requests arrive out of order, so we
+// never hand out more tokens than we have counted locally.
+func (l *outboundLimiter) sync(rl *RateLimit, now time.Time) {
+	if rl == nil || rl.Limit <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(rl.Limit)
+	l.burst = float64(rl.Burst)
+	if l.burst < 1 {
+		l.burst = l.rate
+	}
+	remaining := float64(rl.Remaining)
+	if !l.synced {
+		l.synced = true
+		l.tokens = remaining
+		l.last = now
+	} else {
+		l.refillLocked(now)
+		if remaining < l.tokens {
+			l.tokens = remaining
+		}
+	}
+	if rl.Remaining == 0 && rl.ResetMs > 0 {
+		// Next token not before the reset: 1 - rate*reset tokens from now.
+		if t := 1 - l.rate*float64(rl.ResetMs)/1000; t < l.tokens {
+			l.tokens = t
+		}
+	}
+}
+
+// available returns the tokens left now (+Inf before the first sync).
+func (l *outboundLimiter) available(now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.synced {
+		return math.Inf(1)
+	}
+	l.refillLocked(now)
+	return l.tokens
+}
+
+// fill returns the bucket level from 0 (empty or owing) to 1 (full), or 1
+// before the first sync.
+func (l *outboundLimiter) fill(now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.synced || l.burst <= 0 {
+		return 1
+	}
+	l.refillLocked(now)
+	return min(max(l.tokens/l.burst, 0), 1)
+}
+
+// refund returns part of a token taken for a request that turned out cheap.
+func (l *outboundLimiter) refund(tokens float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.synced {
+		return
+	}
+	l.tokens = min(l.tokens+tokens, l.burst)
+}
+
+func (l *outboundLimiter) refillLocked(now time.Time) {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens += elapsed * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
@@ -0,0 +1,96 @@
+package atlas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOutboundLimiter_UnsyncedDoesNotPace(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		if d := l.reserve(now); d != 0 {
+			t.Fatalf("reserve %d: want 0 before first sync, got %v", i, d)
+		}
+	}
+}
+
+func TestOutboundLimiter_PacesAfterSync(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	l.sync(&RateLimit{Limit: 10, Burst: 2, Remaining: 2}, now)
+
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("first token: want 0, got %v", d)
+	}
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("second token: want 0, got %v", d)
+	}
+	// Bucket empty: 10/s means the third caller waits 100ms, the fourth 200ms.
+	if d := l.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("third token: want 100ms, got %v", d)
+	}
+	if d := l.reserve(now); d != 200*time.Millisecond {
+		t.Errorf("fourth token: want 200ms, got %v", d)
+	}
+}
+
+func TestOutboundLimiter_SyncNeverRaisesTokens(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	l.sync(&RateLimit{Limit: 1, Burst: 5, Remaining: 1}, now)
+	// A late response claims 5 remaining; local count (1) wins.
+	l.sync(&RateLimit{Limit: 1, Burst: 5, Remaining: 5}, now)
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("first token: want 0, got %v", d)
+	}
+	if d := l.reserve(now); d == 0 {
+		t.Error("second token: want wait, got 0 (sync raised tokens)")
+	}
+}
+
+func TestOutboundLimiter_ResetDelaysNextToken(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	l.sync(&RateLimit{Limit: 100, Burst: 10, Remaining: 0, ResetMs: 500}, now)
+	if d := l.reserve(now); d != 500*time.Millisecond {
+		t.Errorf("want 500ms until reset, got %v", d)
+	}
+}
+
+func TestOutboundLimiter_IgnoresMissingHeaders(t *testing.T) {
+	var l outboundLimiter
+	now := time.Now()
+	l.sync(&RateLimit{}, now)
+	l.sync(nil, now)
+	if d := l.reserve(now); d != 0 {
+		t.Errorf("want 0 without rate limit headers, got %v", d)
+	}
+}
+
+func TestGet_PacesFromRateLimitHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "20")
+		w.Header().Set("X-RateLimit-Burst", "1")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	ctx := context.Background()
+	if _, _, err := client.Get(ctx, "/test"); err != nil {
+		t.Fatal(err)
+	}
+	// Remaining=0 at 20/s: the next request must wait ~50ms for a token.
+	start := time.Now()
+	if _, _, err := client.Get(ctx, "/test"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 45*time.Millisecond {
+		t.Errorf("outbound pacing: elapsed %v, want >= 45ms", elapsed)
+	}
+}
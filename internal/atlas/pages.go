@@ -0,0 +1,320 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/config"
+)
+
+// getAllPages fetches all pages from a paginated endpoint and returns merged results.
+// Its PageInfo is only logged; the typed *All accessors return it.
+func (c *Client) getAllPages(ctx context.Context, path string, baseParams map[string]string) ([]byte, *RateLimit, error) {
+	all, info, err := fetchAll[json.RawMessage](ctx, c, path, baseParams)
+	if err != nil {
+		return nil, info.RateLimit, err
+	}
+	out, err := json.Marshal(all)
+	if err != nil {
+		return nil, info.RateLimit, err
+	}
+	return out, info.RateLimit, nil
+}
+
+// SetPageConcurrency sets how many pages fetchAll requests in parallel after
+// the first page. n < 1 is treated as 1 (sequential).
+func (c *Client) SetPageConcurrency(n int) {
+	c.pageWorkers.Store(int32(max(n, 1)))
+}
+
+// pageConcurrency returns the current page concurrency. It drops to 1 while
+// an outbound backoff is active so a 429 is not answered with a burst.
+func (c *Client) pageConcurrency() int {
+	if c.backoffActive() {
+		return 1
+	}
+	return int(c.pageWorkers.Load())
+}
+
+// Page is one page of a paginated Atlas response.
+type Page[T any] struct {
+	Skip       int
+	Items      []T
+	RateLimit  *RateLimit
+	Duplicates int // items dropped because an earlier page had the same id
+
+	ids []int // ids of all items as served, in order
+}
+
+// PageInfo describes how a paginated result was read.
+type PageInfo struct {
+	RateLimit  *RateLimit // from the last page
+	Pages      int
+	Duplicates int  // items dropped because an earlier page had the same id
+	Shifted    bool // items moved between offsets while paginating
+	Reread     bool // the first page and page boundaries were read again after the last page
+	Confirmed  bool // the re-reads matched what was paginated
+}
+
+// PossiblyInconsistent reports whether the result may miss or repeat items:
+// a shift was seen, or the re-read did not confirm it.
+func (i PageInfo) PossiblyInconsistent() bool {
+	return i.Shifted || i.Reread && !i.Confirmed
+}
+
+// Pages returns an iterator over the pages of a paginated endpoint. Pages are
+// yielded in order as they arrive; breaking out of the loop stops pagination
+// and cancels any speculative requests still in flight. Iteration ends after
+// the first page with fewer than take items, or with the first error.
+func (c *Client) Pages(ctx context.Context, path string, params map[string]string) iter.Seq2[Page[json.RawMessage], error] {
+	return walkPages[json.RawMessage](ctx, c, path, params)
+}
+
+// Items returns an iterator over the items of a paginated endpoint, without
+// holding more than the current page window in memory. Duplicates are dropped
+// and logged, but a stream cannot report whether the whole result was
+// consistent; use the typed *All accessors for a PageInfo.
+func (c *Client) Items(ctx context.Context, path string, params map[string]string) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		for page, err := range c.Pages(ctx, path, params) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// fetchAll fetches all pages from a paginated endpoint, decoding each page into []T.
+// With GAMEHUB_ATLAS_PAGE_REREAD set, a multi-page result is confirmed by
+// reading the first page and every page boundary again: if their ids changed,
+// items moved meanwhile.
+func fetchAll[T any](ctx context.Context, c *Client, path string, baseParams map[string]string) ([]T, PageInfo, error) {
+	all := []T{}
+	var info PageInfo
+	var firstIDs []int
+	var bounds []pageBoundary
+	var prev Page[T]
+	for page, err := range walkPages[T](ctx, c, path, baseParams) {
+		if err != nil {
+			return nil, info, err
+		}
+		if page.RateLimit != nil {
+			info.RateLimit = page.RateLimit
+		}
+		if info.Pages == 0 {
+			firstIDs = page.ids
+		} else if len(prev.ids) > 0 && len(page.ids) > 0 {
+			bounds = append(bounds, pageBoundary{skip: page.Skip, ids: []int{prev.ids[len(prev.ids)-1], page.ids[0]}})
+		}
+		prev = page
+		info.Pages++
+		info.Duplicates += page.Duplicates
+		all = append(all, page.Items...)
+	}
+	info.Shifted = info.Duplicates > 0
+	if info.Pages > 1 && config.AtlasPageReread() {
+		info.Reread = true
+		info.Confirmed = c.confirmPages(ctx, path, baseParams, firstIDs, bounds)
+	}
+	if info.PossiblyInconsistent() {
+		log.Printf("pagination: %s possibly inconsistent (%d duplicates, shifted=%v, reread=%v, confirmed=%v)",
+			path, info.Duplicates, info.Shifted, info.Reread, info.Confirmed)
+	}
+	return all, info, nil
+}
+
+// pageBoundary is where one page ended and the next began: the ids of the
+// last item before skip and the first item at skip, as paginated.
+type pageBoundary struct {
+	skip int
+	ids  []int
+}
+
+// confirmPages reports whether the first page still has firstIDs and every
+// boundary still has the same two ids, all in the same order. An item removed
+// from an earlier page after it was read shifts the next page left by one and
+// is missed; the first page catches that for page 0, the boundary the missed
+// item moved back across catches it for later pages.
+func (c *Client) confirmPages(ctx context.Context, path string, baseParams map[string]string, firstIDs []int, bounds []pageBoundary) bool {
+	if !c.sameIDs(ctx, path, pageParams(baseParams, 0, config.PageSize()), firstIDs) {
+		return false
+	}
+	for _, b := range bounds {
+		if !c.sameIDs(ctx, path, pageParams(baseParams, b.skip-1, len(b.ids)), b.ids) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameIDs reads one page and reports whether it has exactly ids, in order.
+func (c *Client) sameIDs(ctx context.Context, path string, params map[string]string, ids []int) bool {
+	body, _, err := c.Get(ctx, buildPath(path, params))
+	if err != nil {
+		return false
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return false
+	}
+	if len(items) != len(ids) {
+		return false
+	}
+	for i, item := range items {
+		if itemID(item) != ids[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func pageParams(baseParams map[string]string, skip, take int) map[string]string {
+	params := make(map[string]string, len(baseParams)+2)
+	for k, v := range baseParams {
+		params[k] = v
+	}
+	params["skip"] = strconv.Itoa(skip)
+	params["take"] = strconv.Itoa(take)
+	return params
+}
+
+// walkPages iterates the pages of a paginated endpoint, decoding each into []T.
+// Follows Atlas pagination: take [0,50], skip [0,∞); stop when fewer than take
+// items or empty array. After the first page it speculatively fetches the next
+// pageConcurrency pages in parallel; pages past the first short one are discarded.
+//
+// Items are deduped by id across pages: if a resource is inserted before the
+// current offset while we paginate, items shift right and the last items of a
+// page come back on the next one. Those repeats are dropped and counted in
+// Page.Duplicates.
+func walkPages[T any](ctx context.Context, c *Client, path string, baseParams map[string]string) iter.Seq2[Page[T], error] {
+	return func(yield func(Page[T], error) bool) {
+		take := config.PageSize()
+		seen := make(map[int]bool)
+		for skip, width := 0, 1; ; skip, width = skip+width*take, c.pageConcurrency() {
+			w := startWindow(ctx, c, path, baseParams, skip, take, width)
+			for i := range width {
+				r := w.wait(i)
+				var page Page[T]
+				if r.err == nil {
+					page, r.err = decodePage[T](path, r.page, seen)
+				}
+				if r.err != nil {
+					w.stop()
+					yield(Page[T]{}, r.err)
+					return
+				}
+				if !yield(page, nil) || len(r.page.Items) < take {
+					w.stop()
+					return
+				}
+			}
+			w.stop()
+		}
+	}
+}
+
+// decodePage drops items whose id is in seen, records the rest in seen and
+// decodes them into T.
+func decodePage[T any](path string, raw Page[json.RawMessage], seen map[int]bool) (Page[T], error) {
+	page := Page[T]{Skip: raw.Skip, RateLimit: raw.RateLimit, Items: make([]T, 0, len(raw.Items))}
+	page.ids = make([]int, len(raw.Items))
+	for i, item := range raw.Items {
+		id := itemID(item)
+		page.ids[i] = id
+		if id != 0 {
+			if seen[id] {
+				page.Duplicates++
+				continue
+			}
+			seen[id] = true
+		}
+		var v T
+		if err := json.Unmarshal(item, &v); err != nil {
+			return Page[T]{}, decodeError(path, err)
+		}
+		page.Items = append(page.Items, v)
+	}
+	if page.Duplicates > 0 && config.Debug() {
+		log.Printf("pagination: %s skip=%d dropped %d duplicates (items shifted)", path, raw.Skip, page.Duplicates)
+	}
+	return page, nil
+}
+
+// pageWindow is a set of consecutive pages being fetched in parallel.
+type pageWindow struct {
+	results []pageResult
+	done    []chan struct{}
+	cancels []context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type pageResult struct {
+	page Page[json.RawMessage]
+	err  error
+}
+
+// startWindow starts fetching width consecutive pages from skip.
+func startWindow(ctx context.Context, c *Client, path string, baseParams map[string]string, skip, take, width int) *pageWindow {
+	w := &pageWindow{
+		results: make([]pageResult, width),
+		done:    make([]chan struct{}, width),
+		cancels: make([]context.CancelFunc, width),
+	}
+	// Per-page contexts: a short page cancels the speculative pages after it.
+	ctxs := make([]context.Context, width)
+	for i := range width {
+		ctxs[i], w.cancels[i] = context.WithCancel(ctx)
+		w.done[i] = make(chan struct{})
+	}
+	for i := range width {
+		w.wg.Go(func() {
+			defer close(w.done[i])
+			pageSkip := skip + i*take
+			body, rl, err := c.Get(ctxs[i], buildPath(path, pageParams(baseParams, pageSkip, take)))
+			if err != nil {
+				w.results[i] = pageResult{err: err}
+				return
+			}
+			var items []json.RawMessage
+			if err := json.Unmarshal(body, &items); err != nil {
+				w.results[i] = pageResult{err: decodeError(path, err)}
+				return
+			}
+			if config.Debug() {
+				log.Printf("pagination: %s skip=%d -> %d items", path, pageSkip, len(items))
+			}
+			w.results[i] = pageResult{page: Page[json.RawMessage]{Skip: pageSkip, Items: items, RateLimit: rl}}
+			if len(items) < take {
+				for _, cancel := range w.cancels[i+1:] {
+					cancel()
+				}
+			}
+		})
+	}
+	return w
+}
+
+// wait blocks until page i of the window has completed.
+func (w *pageWindow) wait(i int) pageResult {
+	<-w.done[i]
+	return w.results[i]
+}
+
+// stop cancels pages still in flight and waits for them to return.
+func (w *pageWindow) stop() {
+	for _, cancel := range w.cancels {
+		cancel()
+	}
+	w.wg.Wait()
+}
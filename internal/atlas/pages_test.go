@@ -0,0 +1,252 @@
+package atlas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedServer serves total items {"id":1..total} with skip/take pagination.
+// It tracks request count and the max number of concurrent requests.
+type pagedServer struct {
+	*httptest.Server
+	requests    atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func newPagedServer(t *testing.T, total int, delay time.Duration) *pagedServer {
+	t.Helper()
+	ps := &pagedServer{}
+	ps.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ps.requests.Add(1)
+		n := ps.inFlight.Add(1)
+		defer ps.inFlight.Add(-1)
+		for {
+			m := ps.maxInFlight.Load()
+			if n <= m || ps.maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+		items := []map[string]int{}
+		for i := skip; i < min(skip+take, total); i++ {
+			items = append(items, map[string]int{"id": i + 1})
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(ps.Close)
+	return ps
+}
+
+func TestFetchAll_ParallelKeepsOrder(t *testing.T) {
+	ps := newPagedServer(t, 260, 10*time.Millisecond) // 5 full pages + 10
+	client := NewClientWithURL("test-secret", ps.URL)
+	client.SetPageConcurrency(3)
+
+	players, _, err := client.PlayersAll(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 260 {
+		t.Fatalf("got %d players, want 260", len(players))
+	}
+	for i, p := range players {
+		if p.ID != i+1 {
+			t.Fatalf("players[%d].ID = %d, want %d (order not deterministic)", i, p.ID, i+1)
+		}
+	}
+	if m := ps.maxInFlight.Load(); m < 2 {
+		t.Errorf("max in-flight %d, want >= 2 (pages not fetched in parallel)", m)
+	}
+	// First page, then windows of 3: skip 50..150, then 200..300 (250 is short).
+	if n := ps.requests.Load(); n != 7 {
+		t.Errorf("got %d requests, want 7", n)
+	}
+}
+
+func TestPageConcurrency_DropsWhileBackingOff(t *testing.T) {
+	client := NewClientWithURL("test-secret", "http://unused")
+	client.SetPageConcurrency(4)
+	client.keys.keys[0].setBackoff(int(time.Hour.Milliseconds()))
+	if got := client.pageConcurrency(); got != 1 {
+		t.Errorf("pageConcurrency during backoff = %d, want 1", got)
+	}
+	client.keys.keys[0].backoffUntil = time.Time{}
+	if got := client.pageConcurrency(); got != 4 {
+		t.Errorf("pageConcurrency without backoff = %d, want 4", got)
+	}
+}
+
+func TestItems_StopsPaginatingOnBreak(t *testing.T) {
+	ps := newPagedServer(t, 500, 0)
+	client := NewClientWithURL("test-secret", ps.URL)
+	client.SetPageConcurrency(1)
+
+	var got []int
+	for item, err := range client.Items(context.Background(), "/players", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		var p Player
+		if err := json.Unmarshal(item, &p); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p.ID)
+		if len(got) == 60 {
+			break
+		}
+	}
+	if len(got) != 60 || got[0] != 1 || got[59] != 60 {
+		t.Errorf("got %d items, want ids 1..60", len(got))
+	}
+	if n := ps.requests.Load(); n != 2 {
+		t.Errorf("got %d requests, want 2 (stop after second page)", n)
+	}
+}
+
+func TestPages_YieldsErrorAndStops(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	client := NewClientWithURL("test-secret", server.URL)
+
+	var pages, errs int
+	for _, err := range client.Pages(context.Background(), "/series", nil) {
+		if err != nil {
+			errs++
+			continue
+		}
+		pages++
+	}
+	if pages != 0 || errs != 1 {
+		t.Errorf("got %d pages and %d errors, want 0 and 1", pages, errs)
+	}
+}
+
+// shiftingServer serves ids from before for the first n requests and from
+// after from then on, like a collection that changes while it is paginated.
+func shiftingServer(t *testing.T, n int32, before, after []int) *httptest.Server {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := after
+		if requests.Add(1) <= n {
+			ids = before
+		}
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+		items := []map[string]int{}
+		for i := skip; i < min(skip+take, len(ids)); i++ {
+			items = append(items, map[string]int{"id": ids[i]})
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetchAll_DedupesShiftedItems(t *testing.T) {
+	t.Setenv("GAMEHUB_PAGE_SIZE", "2")
+	// Item 9 is inserted at the front after the first page: 2 shifts onto page 2.
+	srv := shiftingServer(t, 1, []int{1, 2, 3}, []int{9, 1, 2, 3})
+	client := NewClientWithURL("test-secret", srv.URL)
+	client.SetPageConcurrency(1)
+
+	items, info, err := fetchAll[Ref](context.Background(), client, "/series", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, it := range items {
+		ids = append(ids, it.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("ids = %v, want [1 2 3]", ids)
+	}
+	if info.Duplicates != 1 || !info.Shifted || !info.PossiblyInconsistent() {
+		t.Errorf("info = %+v, want 1 duplicate and possibly inconsistent", info)
+	}
+}
+
+func TestFetchAll_RereadConfirmsUnchanged(t *testing.T) {
+	t.Setenv("GAMEHUB_PAGE_SIZE", "2")
+	t.Setenv("GAMEHUB_ATLAS_PAGE_REREAD", "1")
+	srv := shiftingServer(t, 1, []int{1, 2, 3}, []int{1, 2, 3})
+	client := NewClientWithURL("test-secret", srv.URL)
+
+	_, info, err := fetchAll[Ref](context.Background(), client, "/series", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Reread || !info.Confirmed || info.PossiblyInconsistent() {
+		t.Errorf("info = %+v, want reread and confirmed", info)
+	}
+}
+
+func TestFetchAll_RereadDetectsRemoval(t *testing.T) {
+	t.Setenv("GAMEHUB_PAGE_SIZE", "2")
+	t.Setenv("GAMEHUB_ATLAS_PAGE_REREAD", "1")
+	// Item 1 ends after the first page: 3 moves onto page 1 and is missed.
+	srv := shiftingServer(t, 1, []int{1, 2, 3}, []int{2, 3})
+	client := NewClientWithURL("test-secret", srv.URL)
+	client.SetPageConcurrency(1)
+
+	_, info, err := fetchAll[Ref](context.Background(), client, "/series", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Shifted {
+		t.Errorf("removal produced duplicates: %+v", info)
+	}
+	if !info.Reread || info.Confirmed || !info.PossiblyInconsistent() {
+		t.Errorf("info = %+v, want reread, unconfirmed, possibly inconsistent", info)
+	}
+}
+
+func TestFetchAll_RereadDetectsRemovalFromLaterPage(t *testing.T) {
+	t.Setenv("GAMEHUB_PAGE_SIZE", "2")
+	t.Setenv("GAMEHUB_ATLAS_PAGE_REREAD", "1")
+	// Item 4 ends after the second page: 5 moves onto page 1 and is missed,
+	// while the first page stays the same.
+	srv := shiftingServer(t, 2, []int{1, 2, 3, 4, 5, 6, 7}, []int{1, 2, 3, 5, 6, 7})
+	client := NewClientWithURL("test-secret", srv.URL)
+	client.SetPageConcurrency(1)
+
+	items, info, err := fetchAll[Ref](context.Background(), client, "/series", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, it := range items {
+		ids = append(ids, it.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3 4 6 7]" {
+		t.Fatalf("ids = %v, want 5 missed", ids)
+	}
+	if !info.Reread || info.Confirmed || !info.PossiblyInconsistent() {
+		t.Errorf("info = %+v, want reread, unconfirmed, possibly inconsistent", info)
+	}
+}
+
+func TestFetchAll_SinglePageNotReread(t *testing.T) {
+	t.Setenv("GAMEHUB_ATLAS_PAGE_REREAD", "1")
+	ps := newPagedServer(t, 3, 0)
+	client := NewClientWithURL("test-secret", ps.URL)
+	_, info, err := fetchAll[Ref](context.Background(), client, "/series", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Reread || info.PossiblyInconsistent() || ps.requests.Load() != 1 {
+		t.Errorf("info = %+v after %d requests, want one unchecked page", info, ps.requests.Load())
+	}
+}
@@ -0,0 +1,187 @@
+package atlas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query builds Atlas query parameters (filter, order, fields).
+//
+// Filter grammar: conditions are joined by "," and all must match. Each
+// condition is field, operator, value:
+//
+//	lifecycle=live           equality          (Eq)
+//	lifecycle!=over          inequality        (Ne)
+//	start>=2026-01-02T...Z   comparison        (Lt, Lte, Gt, Gte)
+//	id<={1,2,3}              set membership    (In)
+//
+// Values are formatted per type (ints, bools, time.Time as RFC 3339 UTC) and
+// the reserved characters \ , { } are backslash-escaped in strings. Field names
+// are code constants; an invalid one is a programming error and panics.
+// Order is "field-asc"/"field-desc", comma-joined; fields is comma-joined.
+type Query struct {
+	conds  []string
+	order  []string
+	fields []string
+}
+
+// Direction is a sort direction for OrderBy.
+type Direction string
+
+const (
+	Asc  Direction = "asc"
+	Desc Direction = "desc"
+)
+
+// NewQuery returns an empty query.
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// Clone returns a copy of q that can be extended independently.
+// Clone of a nil Query is an empty Query.
+func (q *Query) Clone() *Query {
+	if q == nil {
+		return NewQuery()
+	}
+	return &Query{
+		conds:  append([]string(nil), q.conds...),
+		order:  append([]string(nil), q.order...),
+		fields: append([]string(nil), q.fields...),
+	}
+}
+
+// Eq adds field=value.
+func (q *Query) Eq(field string, value any) *Query { return q.cond(field, "=", value) }
+
+// Ne adds field!=value.
+func (q *Query) Ne(field string, value any) *Query { return q.cond(field, "!=", value) }
+
+// Lt adds field<value.
+func (q *Query) Lt(field string, value any) *Query { return q.cond(field, "<", value) }
+
+// Lte adds field<=value.
+func (q *Query) Lte(field string, value any) *Query { return q.cond(field, "<=", value) }
+
+// Gt adds field>value.
+func (q *Query) Gt(field string, value any) *Query { return q.cond(field, ">", value) }
+
+// Gte adds field>=value.
+func (q *Query) Gte(field string, value any) *Query { return q.cond(field, ">=", value) }
+
+// In adds field<={v1,v2,...}: field is one of values.
+func (q *Query) In(field string, values ...any) *Query {
+	checkField(field)
+	var b strings.Builder
+	b.WriteString(field)
+	b.WriteString("<={")
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(formatValue(v))
+	}
+	b.WriteByte('}')
+	q.conds = append(q.conds, b.String())
+	return q
+}
+
+// InInts is In for a slice of ints, e.g. IDs.
+func (q *Query) InInts(field string, values []int) *Query {
+	vs := make([]any, len(values))
+	for i, v := range values {
+		vs[i] = v
+	}
+	return q.In(field, vs...)
+}
+
+// OrderBy appends a sort key.
+func (q *Query) OrderBy(field string, dir Direction) *Query {
+	checkField(field)
+	q.order = append(q.order, field+"-"+string(dir))
+	return q
+}
+
+// Fields limits the response to the given fields.
+func (q *Query) Fields(fields ...string) *Query {
+	for _, f := range fields {
+		checkField(f)
+	}
+	q.fields = append(q.fields, fields...)
+	return q
+}
+
+// Filter returns the filter expression ("" if no conditions).
+func (q *Query) Filter() string {
+	return strings.Join(q.conds, ",")
+}
+
+// Params returns the query as params for the Client Get* methods.
+func (q *Query) Params() map[string]string {
+	params := make(map[string]string, 3)
+	if len(q.conds) > 0 {
+		params["filter"] = q.Filter()
+	}
+	if len(q.order) > 0 {
+		params["order"] = strings.Join(q.order, ",")
+	}
+	if len(q.fields) > 0 {
+		params["fields"] = strings.Join(q.fields, ",")
+	}
+	return params
+}
+
+func (q *Query) cond(field, op string, value any) *Query {
+	checkField(field)
+	q.conds = append(q.conds, field+op+formatValue(value))
+	return q
+}
+
+// checkField panics unless field is a dotted path of [a-z0-9_] segments.
+func checkField(field string) {
+	if field == "" || field[0] == '.' || field[len(field)-1] == '.' || strings.Contains(field, "..") {
+		panic(fmt.Sprintf("atlas: invalid query field %q", field))
+	}
+	for _, r := range field {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '.') {
+			panic(fmt.Sprintf("atlas: invalid query field %q", field))
+		}
+	}
+}
+
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case string:
+		return escapeValue(x)
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339)
+	case fmt.Stringer:
+		return escapeValue(x.String())
+	default:
+		return escapeValue(fmt.Sprint(x))
+	}
+}
+
+// escapeValue backslash-escapes the characters the filter grammar reserves.
+func escapeValue(s string) string {
+	if !strings.ContainsAny(s, `\,{}`) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', ',', '{', '}':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
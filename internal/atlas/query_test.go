@@ -0,0 +1,81 @@
+package atlas
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestQuery_Filter(t *testing.T) {
+	start := time.Date(2026, 1, 2, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		name string
+		q    *Query
+		want string
+	}{
+		{"empty", NewQuery(), ""},
+		{"eq", NewQuery().Eq("lifecycle", "live"), "lifecycle=live"},
+		{"ne", NewQuery().Ne("lifecycle", "over"), "lifecycle!=over"},
+		{"comparisons", NewQuery().Gt("tier", 1).Lte("tier", 3), "tier>1,tier<=3"},
+		{"lt gte", NewQuery().Lt("id", 10).Gte("id", 5), "id<10,id>=5"},
+		{"time is RFC 3339 UTC", NewQuery().Gte("start", start), "start>=2026-01-02T14:04:05Z"},
+		{"bool", NewQuery().Eq("deleted", false), "deleted=false"},
+		{"in ints", NewQuery().InInts("id", []int{1, 2, 3}), "id<={1,2,3}"},
+		{"in strings", NewQuery().In("lifecycle", "live", "upcoming"), "lifecycle<={live,upcoming}"},
+		{"in empty", NewQuery().InInts("id", nil), "id<={}"},
+		{"nested field", NewQuery().Eq("game.id", 5), "game.id=5"},
+		{"combined", NewQuery().Eq("lifecycle", "live").InInts("game.id", []int{1, 2}), "lifecycle=live,game.id<={1,2}"},
+		{"escapes reserved", NewQuery().Eq("title", `a,b{c}\d`), `title=a\,b\{c\}\\d`},
+		{"escapes in set", NewQuery().In("title", "x,y", "z"), `title<={x\,y,z}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Filter(); got != tt.want {
+				t.Errorf("Filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_Params(t *testing.T) {
+	q := NewQuery().Eq("lifecycle", "live").OrderBy("start", Desc).OrderBy("id", Asc).Fields("id", "title")
+	params := q.Params()
+	want := map[string]string{
+		"filter": "lifecycle=live",
+		"order":  "start-desc,id-asc",
+		"fields": "id,title",
+	}
+	if len(params) != len(want) {
+		t.Fatalf("Params() = %v, want %v", params, want)
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("params[%q] = %q, want %q", k, params[k], v)
+		}
+	}
+	// Round-trips through URL encoding unchanged.
+	path := buildPath("/series", NewQuery().Eq("title", "a&b=c").Params())
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("filter"); got != "title=a&b=c" {
+		t.Errorf("encoded filter round-trip = %q", got)
+	}
+	if got := len(NewQuery().Params()); got != 0 {
+		t.Errorf("empty query: want no params, got %d", got)
+	}
+}
+
+func TestQuery_InvalidFieldPanics(t *testing.T) {
+	for _, field := range []string{"", "Title", "a b", "id=1", "a..b", ".a", "a."} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("field %q: want panic", field)
+				}
+			}()
+			NewQuery().Eq(field, 1)
+		}()
+	}
+}
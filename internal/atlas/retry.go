@@ -0,0 +1,132 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+)
+
+// RetryPolicy controls how Client retries transient Atlas failures: 5xx,
+// connection resets, timeouts and 429. Attach one to a call with
+// WithRetryPolicy; calls without one use DefaultRetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; <= 1 disables retries
+	BaseBackoff time.Duration // wait before the second attempt; doubles per retry
+	MaxBackoff  time.Duration // cap for a single wait
+	Jitter      float64       // fraction [0,1] of each wait that is randomised
+	Budget      time.Duration // max total time per call incl. waits; 0 = bounded by ctx only
+}
+
+// NoRetry makes a single attempt and returns its error as is.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryPolicy returns the policy for calls without one attached.
+// Env: GAMEHUB_ATLAS_RETRY_MAX_ATTEMPTS, GAMEHUB_ATLAS_RETRY_BASE_BACKOFF,
+// GAMEHUB_ATLAS_RETRY_MAX_BACKOFF, GAMEHUB_ATLAS_RETRY_BUDGET.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: config.AtlasRetryMaxAttempts(),
+		BaseBackoff: config.AtlasRetryBaseBackoff(),
+		MaxBackoff:  config.AtlasRetryMaxBackoff(),
+		Jitter:      0.5,
+		Budget:      config.AtlasRetryBudget(),
+	}
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context whose Client calls use p.
+func WithRetryPolicy(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+func retryPolicyFrom(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return p
+	}
+	return DefaultRetryPolicy()
+}
+
+// backoff returns the jittered wait after the given failed attempt (1-based).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		j := min(p.Jitter, 1)
+		d = time.Duration(float64(d) * (1 - j + j*rand.Float64()))
+	}
+	return d
+}
+
+// nextWait reports whether to retry after err on the given attempt, and how
+// long to wait first. It gives up when the wait would overrun the retry
+// budget or the context deadline.
+func (p RetryPolicy) nextWait(ctx context.Context, attempt int, err error, start time.Time) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+		return 0, false
+	}
+	wait := p.backoff(attempt)
+	var rlErr *ErrRateLimited
+	if errors.As(err, &rlErr) {
+		wait = max(wait, time.Duration(rlErr.RetryAfterMs)*time.Millisecond)
+	}
+	if p.Budget > 0 && time.Since(start)+wait >= p.Budget {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+		return 0, false
+	}
+	return wait, true
+}
+
+// retryable reports whether err is a transient failure worth retrying.
+func retryable(err error) bool {
+	var rlErr *ErrRateLimited
+	if errors.As(err, &rlErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestGet_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	body, _, err := client.Get(WithRetryPolicy(context.Background(), fastRetry), "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "[]" {
+		t.Errorf("body: want [], got %s", body)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("want 3 attempts, got %d", n)
+	}
+}
+
+func TestGet_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "no such thing", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	_, _, err := client.Get(WithRetryPolicy(context.Background(), fastRetry), "/test")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("want APIError 404, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("want 1 attempt, got %d", n)
+	}
+}
+
+func TestGet_RetriesRateLimitAfterRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	start := time.Now()
+	if _, _, err := client.Get(WithRetryPolicy(context.Background(), fastRetry), "/test"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("elapsed %v, want >= 25ms (Retry-After honoured)", elapsed)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("want 2 attempts, got %d", n)
+	}
+}
+
+func TestGet_NoRetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	if _, _, err := client.Get(WithRetryPolicy(context.Background(), NoRetry), "/test"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("want 1 attempt, got %d", n)
+	}
+}
+
+func TestGet_RetryStopsAtBudgetAndDeadline(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClientWithURL("test-secret", server.URL)
+
+	slow := RetryPolicy{MaxAttempts: 10, BaseBackoff: 50 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Budget: 20 * time.Millisecond}
+	if _, _, err := client.Get(WithRetryPolicy(context.Background(), slow), "/test"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if n := calls.Swap(0); n != 1 {
+		t.Errorf("budget: want 1 attempt (wait exceeds budget), got %d", n)
+	}
+
+	slow.Budget = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := client.Get(WithRetryPolicy(ctx, slow), "/test"); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("deadline: want 1 attempt (wait exceeds deadline), got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("deadline: elapsed %v, want fail fast", elapsed)
+	}
+}
+
+func TestRetryPolicy_BackoffCappedWithJitter(t *testing.T) {
+	p := RetryPolicy{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 40 * time.Millisecond, Jitter: 0.5}
+	for attempt := 1; attempt <= 6; attempt++ {
+		want := min(10*time.Millisecond<<(attempt-1), 40*time.Millisecond)
+		for i := 0; i < 20; i++ {
+			d := p.backoff(attempt)
+			if d < want/2 || d > want {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+}
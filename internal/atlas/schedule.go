@@ -0,0 +1,138 @@
+package atlas
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// Priority is the scheduling class of an outbound request.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota // a GameHub client is waiting (default)
+	PriorityWarm                        // cache revalidations and refreshes
+	PriorityBackground                  // jobs nobody is waiting for
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityWarm:
+		return "warm"
+	case PriorityBackground:
+		return "background"
+	default:
+		return "interactive"
+	}
+}
+
+// ErrShed is returned without contacting Atlas when a request is dropped: its
+// priority queue is full, or the remaining Atlas budget is reserved for higher
+// priorities. It matches ErrUnavailable.
+var ErrShed = fmt.Errorf("%w: request shed", ErrUnavailable)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose Atlas calls are scheduled with p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// scheduler orders requests waiting to be paced. One request at a time holds
+// the gate while it waits for a key's backoff and token; the others queue per
+// priority and get the gate highest priority first, FIFO within a class. When
+// nobody is waiting the gate is passed on immediately, so it costs nothing
+// until Atlas pushes back.
+type scheduler struct {
+	queueSize int                // max queued requests per priority
+	shedPct   [numPriorities]int // shed when the budget is below this percent (0 = never)
+
+	mu     sync.Mutex
+	busy   bool // a request holds the gate
+	queues [numPriorities][]chan struct{}
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{
+		queueSize: config.AtlasQueueSize(),
+		shedPct: [numPriorities]int{
+			PriorityWarm:       config.AtlasShedWarmPct(),
+			PriorityBackground: config.AtlasShedBackgroundPct(),
+		},
+	}
+}
+
+// acquire waits for the gate. budget returns the share of Atlas quota left
+// (0..1). The caller must call release once it is done waiting.
+func (s *scheduler) acquire(ctx context.Context, p Priority, budget func() float64) error {
+	if pct := s.shedPct[p]; pct > 0 && budget()*100 < float64(pct) {
+		metrics.AtlasShed.Add(1)
+		return ErrShed
+	}
+	s.mu.Lock()
+	if !s.busy {
+		s.busy = true
+		s.mu.Unlock()
+		return nil
+	}
+	if len(s.queues[p]) >= s.queueSize {
+		s.mu.Unlock()
+		metrics.AtlasShed.Add(1)
+		return ErrShed
+	}
+	ready := make(chan struct{})
+	s.queues[p] = append(s.queues[p], ready)
+	metrics.AtlasQueued.Add(1)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		removed := s.removeLocked(p, ready)
+		s.mu.Unlock()
+		if !removed {
+			// Granted while giving up: pass the gate on.
+			s.release()
+		}
+		return ctx.Err()
+	}
+}
+
+// release passes the gate to the next queued request, if any.
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.queues {
+		if q := s.queues[p]; len(q) > 0 {
+			s.queues[p] = q[1:]
+			metrics.AtlasQueued.Add(-1)
+			close(q[0])
+			return
+		}
+	}
+	s.busy = false
+}
+
+func (s *scheduler) removeLocked(p Priority, ready chan struct{}) bool {
+	for i, c := range s.queues[p] {
+		if c == ready {
+			s.queues[p] = append(s.queues[p][:i], s.queues[p][i+1:]...)
+			metrics.AtlasQueued.Add(-1)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package atlas
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func full() float64 { return 1 }
+
+func testScheduler(queueSize int) *scheduler {
+	return &scheduler{queueSize: queueSize, shedPct: [numPriorities]int{PriorityWarm: 5, PriorityBackground: 20}}
+}
+
+// waitQueued waits until n requests are queued at p.
+func waitQueued(t *testing.T, s *scheduler, p Priority, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		got := len(s.queues[p])
+		s.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queue %s: want %d waiting", p, n)
+}
+
+func TestScheduler_GrantsByPriority(t *testing.T) {
+	s := testScheduler(10)
+	ctx := context.Background()
+	if err := s.acquire(ctx, PriorityInteractive, full); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	for _, p := range []Priority{PriorityBackground, PriorityWarm, PriorityInteractive} {
+		wg.Go(func() {
+			if err := s.acquire(ctx, p, full); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			s.release()
+		})
+		waitQueued(t, s, p, 1)
+	}
+	s.release()
+	wg.Wait()
+
+	want := []Priority{PriorityInteractive, PriorityWarm, PriorityBackground}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("grant order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestScheduler_ShedsWhenQueueFull(t *testing.T) {
+	s := testScheduler(1)
+	ctx := context.Background()
+	if err := s.acquire(ctx, PriorityInteractive, full); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = s.acquire(ctx, PriorityWarm, full) }()
+	waitQueued(t, s, PriorityWarm, 1)
+
+	if err := s.acquire(ctx, PriorityWarm, full); !errors.Is(err, ErrShed) {
+		t.Errorf("full queue: want ErrShed, got %v", err)
+	}
+	s.release() // to the queued warm request
+	s.release()
+}
+
+func TestScheduler_ShedsLowPriorityOnLowBudget(t *testing.T) {
+	s := testScheduler(10)
+	ctx := context.Background()
+	low := func() float64 { return 0.1 }
+	if err := s.acquire(ctx, PriorityBackground, low); !errors.Is(err, ErrShed) {
+		t.Errorf("background at 10%%: want ErrShed, got %v", err)
+	}
+	if !errors.Is(ErrShed, ErrUnavailable) {
+		t.Error("ErrShed should match ErrUnavailable")
+	}
+	if err := s.acquire(ctx, PriorityWarm, low); err != nil {
+		t.Errorf("warm at 10%%: want admitted, got %v", err)
+	}
+	s.release()
+	if err := s.acquire(ctx, PriorityInteractive, func() float64 { return 0 }); err != nil {
+		t.Errorf("interactive at 0%%: want admitted, got %v", err)
+	}
+	s.release()
+}
+
+func TestScheduler_CancelWhileQueued(t *testing.T) {
+	s := testScheduler(10)
+	if err := s.acquire(context.Background(), PriorityInteractive, full); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.acquire(ctx, PriorityWarm, full) }()
+	waitQueued(t, s, PriorityWarm, 1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("want Canceled, got %v", err)
+	}
+	waitQueued(t, s, PriorityWarm, 0)
+	s.release()
+	if err := s.acquire(context.Background(), PriorityBackground, full); err != nil {
+		t.Errorf("gate not free after release: %v", err)
+	}
+	s.release()
+}
+
+func TestGet_ShedsBackgroundWhileBackingOff(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL)
+	client.keys.keys[0].setBackoff(50)
+
+	bg := WithRetryPolicy(WithPriority(context.Background(), PriorityBackground), NoRetry)
+	if _, _, err := client.Get(bg, "/series"); !errors.Is(err, ErrShed) {
+		t.Errorf("background during backoff: want ErrShed, got %v", err)
+	}
+	if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+		t.Errorf("interactive during backoff: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("want 1 request (interactive only), got %d", n)
+	}
+}
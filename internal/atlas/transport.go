@@ -0,0 +1,158 @@
+package atlas
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// Every Atlas request goes through a chain of round-trippers, outermost first:
+//
+//	metrics ─▶ backoff ─▶ auth ─▶ WithMiddleware... ─▶ transport
+//
+// metrics counts requests and 429s, backoff syncs the key's token bucket from
+// the rate limit headers and starts a backoff on 429, auth sets Abios-Secret.
+// Custom middleware sees the authenticated request, and whatever it returns
+// (including injected faults) is what pacing and metrics react to. Waiting for
+// a token or a backoff happens before the chain, outside the HTTP timeout.
+
+// Middleware wraps a RoundTripper, e.g. to log, add headers or inject faults.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps rt in mw; the first middleware is the outermost.
+func Chain(rt http.RoundTripper, mw ...Middleware) http.RoundTripper {
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
+	}
+	return rt
+}
+
+// Option configures a Client.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	httpClient *http.Client
+	transport  http.RoundTripper
+	middleware []Middleware
+}
+
+// WithHTTPClient uses hc (a copy of it) for requests; its Transport is wrapped
+// by the client's round-tripper chain. Without it, the client uses an
+// http.Client with GAMEHUB_ATLAS_CLIENT_TIMEOUT.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *clientOptions) { o.httpClient = hc }
+}
+
+// WithTransport sets the innermost RoundTripper (default http.DefaultTransport,
+// or the HTTP client's own Transport).
+func WithTransport(rt http.RoundTripper) Option {
+	return func(o *clientOptions) { o.transport = rt }
+}
+
+// WithMiddleware appends middleware between the built-in round-trippers and
+// the transport. The first middleware is the outermost.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(o *clientOptions) { o.middleware = append(o.middleware, mw...) }
+}
+
+// newHTTPClient builds the client's http.Client from opts. With
+// GAMEHUB_ATLAS_REPLAY_DIR set the transport is a replayer; with
+// GAMEHUB_ATLAS_RECORD_DIR set it is wrapped by a recorder.
+func (c *Client) newHTTPClient(opts []Option) *http.Client {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	hc := &http.Client{Timeout: config.AtlasClientTimeout()}
+	if o.httpClient != nil {
+		copied := *o.httpClient
+		hc = &copied
+	}
+	base := hc.Transport
+	if o.transport != nil {
+		base = o.transport
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if dir := config.AtlasReplayDir(); dir != "" {
+		base = NewReplayer(dir)
+	} else if dir := config.AtlasRecordDir(); dir != "" {
+		base = NewRecorder(dir, base)
+	}
+	builtin := []Middleware{metricsMiddleware, c.backoffMiddleware, authMiddleware}
+	hc.Transport = Chain(base, append(builtin, o.middleware...)...)
+	return hc
+}
+
+type attemptKey struct{}
+
+// withKey attaches the API key chosen for an attempt to its request context.
+func withKey(ctx context.Context, k *apiKey) context.Context {
+	return context.WithValue(ctx, attemptKey{}, k)
+}
+
+func keyFrom(ctx context.Context) *apiKey {
+	k, _ := ctx.Value(attemptKey{}).(*apiKey)
+	return k
+}
+
+// authMiddleware sets Abios-Secret to the attempt's API key.
+func authMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if k := keyFrom(req.Context()); k != nil {
+			req = req.Clone(req.Context())
+			req.Header.Set("Abios-Secret", k.secret)
+		}
+		return next.RoundTrip(req)
+	})
+}
+
+// metricsMiddleware counts requests and 429s, per key and in total.
+func metricsMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		k := keyFrom(req.Context())
+		if k != nil {
+			k.stats.Requests.Add(1)
+		}
+		resp, err := next.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			metrics.Atlas429.Add(1)
+			metrics.RecordAtlasRetryAfter(parseRetryAfter(resp.Header.Get("Retry-After")))
+			if k != nil {
+				k.stats.Limited.Add(1)
+			}
+		}
+		return resp, err
+	})
+}
+
+// backoffMiddleware keeps the attempt key's pacing state current: it syncs the
+// token bucket from X-RateLimit-* headers, backs the key off for Retry-After on
+// 429 and refunds most of the token for a 304.
+func (c *Client) backoffMiddleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		k := keyFrom(req.Context())
+		if err != nil || k == nil {
+			return resp, err
+		}
+		c.keys.observe(k, parseRateLimit(resp.Header))
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			k.setBackoff(parseRetryAfter(resp.Header.Get("Retry-After")))
+		case http.StatusNotModified:
+			k.limiter.refund(1 - notModifiedCost)
+		}
+		return resp, err
+	})
+}
@@ -0,0 +1,126 @@
+package atlas
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// staticTransport answers every request with status and body.
+func staticTransport(status int, body string, header http.Header) RoundTripperFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		h := http.Header{}
+		for k, v := range header {
+			h[k] = v
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     h,
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+}
+
+func TestWithMiddleware_OrderAndAuthenticatedRequest(t *testing.T) {
+	var order []string
+	var sawSecret string
+	tag := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				sawSecret = req.Header.Get("Abios-Secret")
+				req.Header.Set("X-Trace", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Trace"); got != "inner" {
+			t.Errorf("X-Trace = %q, want inner", got)
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithURL("test-secret", server.URL, WithMiddleware(tag("outer"), tag("inner")))
+	if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("middleware order %v, want outer,inner", order)
+	}
+	if sawSecret != "test-secret" {
+		t.Errorf("middleware saw Abios-Secret %q, want test-secret", sawSecret)
+	}
+}
+
+func TestWithMiddleware_InjectedFaultDrivesBackoff(t *testing.T) {
+	inject429 := func(next http.RoundTripper) http.RoundTripper {
+		return staticTransport(http.StatusTooManyRequests, "", http.Header{"Retry-After": {"60000"}})
+	}
+	client := NewClientWithURL("test-secret", "http://unused", WithMiddleware(inject429))
+
+	before := metrics.Atlas429.Load()
+	ctx := WithRetryPolicy(context.Background(), NoRetry)
+	if _, _, err := client.Get(ctx, "/series"); err == nil {
+		t.Fatal("want ErrRateLimited")
+	}
+	if got := metrics.Atlas429.Load() - before; got != 1 {
+		t.Errorf("atlas 429 count +%d, want +1", got)
+	}
+	if !client.backoffActive() {
+		t.Error("injected 429 did not start a backoff")
+	}
+}
+
+func TestWithTransport_ServesWithoutNetwork(t *testing.T) {
+	header := http.Header{"X-Ratelimit-Limit": {"5"}, "X-Ratelimit-Remaining": {"4"}}
+	client := NewClientWithURL("test-secret", "http://unused", WithTransport(staticTransport(http.StatusOK, `[{"id":7}]`, header)))
+	body, rl, err := client.Get(context.Background(), "/series")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `[{"id":7}]` {
+		t.Errorf("body %s", body)
+	}
+	if rl.Remaining != 4 {
+		t.Errorf("remaining %d, want 4", rl.Remaining)
+	}
+	if got := client.keys.keys[0].limiter.available(time.Now()); got >= 5 {
+		t.Errorf("key tokens %v after sync, want about 4", got)
+	}
+}
+
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return staticTransport(http.StatusOK, `[]`, nil)(req)
+}
+
+func TestWithHTTPClient_DoesNotModifyCallerClient(t *testing.T) {
+	base := &countingTransport{}
+	hc := &http.Client{Timeout: time.Second, Transport: base}
+	client := NewClientWithURL("test-secret", "http://unused", WithHTTPClient(hc))
+	if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+		t.Fatal(err)
+	}
+	if base.calls != 1 {
+		t.Errorf("caller's transport called %d times, want 1", base.calls)
+	}
+	if client.httpClient.Timeout != time.Second {
+		t.Errorf("timeout %v, want the caller's 1s", client.httpClient.Timeout)
+	}
+	if hc.Transport != base {
+		t.Error("caller's client transport was replaced")
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,6 +22,16 @@ func envInt(name string, defaultVal int) int {
 	return defaultVal
 }
 
+// envBool returns env value as bool (1, true, 0, false, ...), or default if unset/invalid.
+func envBool(name string, defaultVal bool) bool {
+	if s := os.Getenv(name); s != "" {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 // envDuration returns env value as duration, or default if unset/invalid.
 func envDuration(name string, defaultVal time.Duration) time.Duration {
 	if s := os.Getenv(name); s != "" {
@@ -31,11 +42,86 @@ func envDuration(name string, defaultVal time.Duration) time.Duration {
 	return defaultVal
 }
 
+// envWindow is like envDuration but accepts 0, which turns the window off.
+func envWindow(name string, defaultVal time.Duration) time.Duration {
+	if s := os.Getenv(name); s != "" {
+		if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return defaultVal
+}
+
 // PageSize returns Atlas API page size [0, 50]. Env: GAMEHUB_PAGE_SIZE.
 func PageSize() int {
 	return envInt("GAMEHUB_PAGE_SIZE", 50)
 }
 
+// AtlasPageConcurrency returns how many pages are fetched in parallel after the first. Env: GAMEHUB_ATLAS_PAGE_CONCURRENCY.
+func AtlasPageConcurrency() int {
+	return envInt("GAMEHUB_ATLAS_PAGE_CONCURRENCY", 4)
+}
+
+// AtlasPageReread reports whether multi-page results are confirmed by re-reading the first page and page boundaries. Env: GAMEHUB_ATLAS_PAGE_REREAD.
+func AtlasPageReread() bool {
+	return envBool("GAMEHUB_ATLAS_PAGE_REREAD", false)
+}
+
+// AtlasIDChunkSize returns the max IDs per id<={...} filter; larger sets are split. Env: GAMEHUB_ATLAS_ID_CHUNK_SIZE.
+func AtlasIDChunkSize() int {
+	return envInt("GAMEHUB_ATLAS_ID_CHUNK_SIZE", 100)
+}
+
+// AtlasIDChunkConcurrency returns how many ID chunks are fetched in parallel. Env: GAMEHUB_ATLAS_ID_CHUNK_CONCURRENCY.
+func AtlasIDChunkConcurrency() int {
+	return envInt("GAMEHUB_ATLAS_ID_CHUNK_CONCURRENCY", 2)
+}
+
+// AtlasQueueSize returns how many Atlas requests may queue per priority before more are shed. Env: GAMEHUB_ATLAS_QUEUE_SIZE.
+func AtlasQueueSize() int {
+	return envInt("GAMEHUB_ATLAS_QUEUE_SIZE", 256)
+}
+
+// AtlasShedWarmPct returns the remaining Atlas budget (%) below which cache revalidations and refreshes are shed. Env: GAMEHUB_ATLAS_SHED_WARM_PCT.
+func AtlasShedWarmPct() int {
+	return envInt("GAMEHUB_ATLAS_SHED_WARM_PCT", 5)
+}
+
+// AtlasShedBackgroundPct returns the remaining Atlas budget (%) below which background requests are shed. Env: GAMEHUB_ATLAS_SHED_BACKGROUND_PCT.
+func AtlasShedBackgroundPct() int {
+	return envInt("GAMEHUB_ATLAS_SHED_BACKGROUND_PCT", 20)
+}
+
+// AtlasAPIKeys returns the Atlas API keys: the comma-separated ATLAS_API_KEYS,
+// or ATLAS_API_KEY if that is unset. Blank and duplicate keys are dropped.
+func AtlasAPIKeys() []string {
+	raw := os.Getenv("ATLAS_API_KEYS")
+	if raw == "" {
+		raw = os.Getenv("ATLAS_API_KEY")
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, k := range strings.Split(raw, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// AtlasRecordDir returns the directory to record Atlas request/response cassettes to ("" = off). Env: GAMEHUB_ATLAS_RECORD_DIR.
+func AtlasRecordDir() string {
+	return os.Getenv("GAMEHUB_ATLAS_RECORD_DIR")
+}
+
+// AtlasReplayDir returns the directory to replay Atlas cassettes from instead of the network ("" = off). Env: GAMEHUB_ATLAS_REPLAY_DIR.
+func AtlasReplayDir() string {
+	return os.Getenv("GAMEHUB_ATLAS_REPLAY_DIR")
+}
+
 // InboundRateLimitRequests returns requests per IP per window. Env: GAMEHUB_INBOUND_RATE_LIMIT.
 func InboundRateLimitRequests() int {
 	return envInt("GAMEHUB_INBOUND_RATE_LIMIT", 60)
@@ -66,12 +152,144 @@ func LiveCacheTTL() time.Duration {
 	return envDuration("GAMEHUB_LIVE_CACHE_TTL", 10*time.Second)
 }
 
+// LiveRefreshAhead returns how long before the live context expires the
+// background refresher reloads it. Env: GAMEHUB_LIVE_REFRESH_AHEAD.
+func LiveRefreshAhead() time.Duration {
+	return envDuration("GAMEHUB_LIVE_REFRESH_AHEAD", 2*time.Second)
+}
+
+// LiveRefreshMaxInterval caps the refresher's wait after repeated failures. Env: GAMEHUB_LIVE_REFRESH_MAX_INTERVAL.
+func LiveRefreshMaxInterval() time.Duration {
+	return envDuration("GAMEHUB_LIVE_REFRESH_MAX_INTERVAL", time.Minute)
+}
+
+// LiveStaleWhileRevalidate returns how long past its TTL the live context is
+// still served while a background load replaces it (0 = off). Env: GAMEHUB_LIVE_STALE_WHILE_REVALIDATE.
+func LiveStaleWhileRevalidate() time.Duration {
+	return envWindow("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", 10*time.Second)
+}
+
+// LiveStaleIfError returns how long past its TTL the live context is still
+// served when a load fails (0 = off). Env: GAMEHUB_LIVE_STALE_IF_ERROR.
+func LiveStaleIfError() time.Duration {
+	return envWindow("GAMEHUB_LIVE_STALE_IF_ERROR", 5*time.Minute)
+}
+
+// LiveEventHistory returns how many live change events are kept for resuming
+// subscribers. Env: GAMEHUB_LIVE_EVENT_HISTORY.
+func LiveEventHistory() int {
+	return envInt("GAMEHUB_LIVE_EVENT_HISTORY", 1024)
+}
+
+// SSEHeartbeat returns how often /events/live sends a comment to keep idle
+// connections open. Env: GAMEHUB_SSE_HEARTBEAT.
+func SSEHeartbeat() time.Duration {
+	return envDuration("GAMEHUB_SSE_HEARTBEAT", 15*time.Second)
+}
+
+// SSEBuffer returns how many events an /events/live client may fall behind
+// before it is dropped. Env: GAMEHUB_SSE_BUFFER.
+func SSEBuffer() int {
+	return envInt("GAMEHUB_SSE_BUFFER", 64)
+}
+
+// WSPingInterval returns how often /ws pings clients; a client silent for two
+// intervals is disconnected. Env: GAMEHUB_WS_PING_INTERVAL.
+func WSPingInterval() time.Duration {
+	return envDuration("GAMEHUB_WS_PING_INTERVAL", 30*time.Second)
+}
+
+// WSMaxSubscriptions returns how many topics one /ws connection may subscribe
+// to. Env: GAMEHUB_WS_MAX_SUBSCRIPTIONS.
+func WSMaxSubscriptions() int {
+	return envInt("GAMEHUB_WS_MAX_SUBSCRIPTIONS", 50)
+}
+
+// WSAllowedOrigins returns the browser origins, besides the server's own host,
+// that may open /ws: comma-separated "scheme://host[:port]" values. Env:
+// GAMEHUB_WS_ALLOWED_ORIGINS.
+func WSAllowedOrigins() []string {
+	var origins []string
+	for _, o := range strings.Split(os.Getenv("GAMEHUB_WS_ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// WSBuffer returns how many live events a /ws connection may fall behind
+// before it is closed. Env: GAMEHUB_WS_BUFFER.
+func WSBuffer() int {
+	return envInt("GAMEHUB_WS_BUFFER", 64)
+}
+
+// LiveLoadTimeout returns the deadline for one live context load (series -> rosters). Env: GAMEHUB_LIVE_LOAD_TIMEOUT.
+func LiveLoadTimeout() time.Duration {
+	return envDuration("GAMEHUB_LIVE_LOAD_TIMEOUT", 20*time.Second)
+}
+
 // AtlasClientTimeout returns Atlas API client HTTP timeout. Env: GAMEHUB_ATLAS_CLIENT_TIMEOUT.
 func AtlasClientTimeout() time.Duration {
 	return envDuration("GAMEHUB_ATLAS_CLIENT_TIMEOUT", 30*time.Second)
 }
 
+// AtlasKeyDisableFor returns how long an API key Atlas rejected is left out of the pool. Env: GAMEHUB_ATLAS_KEY_DISABLE_FOR.
+func AtlasKeyDisableFor() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_KEY_DISABLE_FOR", 5*time.Minute)
+}
+
 // AtlasOutboundMinBackoff returns minimum backoff on 429 when Retry-After is missing. Env: GAMEHUB_ATLAS_OUTBOUND_MIN_BACKOFF.
 func AtlasOutboundMinBackoff() time.Duration {
 	return envDuration("GAMEHUB_ATLAS_OUTBOUND_MIN_BACKOFF", time.Second)
 }
+
+// AtlasConditionalCacheSize returns how many paths keep ETag/Last-Modified for conditional requests. Env: GAMEHUB_ATLAS_CONDITIONAL_CACHE_SIZE.
+func AtlasConditionalCacheSize() int {
+	return envInt("GAMEHUB_ATLAS_CONDITIONAL_CACHE_SIZE", 512)
+}
+
+// AtlasBreakerWindow returns how many recent Atlas outcomes the circuit breaker evaluates. Env: GAMEHUB_ATLAS_BREAKER_WINDOW.
+func AtlasBreakerWindow() int {
+	return envInt("GAMEHUB_ATLAS_BREAKER_WINDOW", 20)
+}
+
+// AtlasBreakerFailurePct returns the failure percentage within the window that opens the breaker. Env: GAMEHUB_ATLAS_BREAKER_FAILURE_PCT.
+func AtlasBreakerFailurePct() int {
+	return envInt("GAMEHUB_ATLAS_BREAKER_FAILURE_PCT", 50)
+}
+
+// AtlasBreakerSlowCall returns the latency above which an Atlas call counts as failed. Env: GAMEHUB_ATLAS_BREAKER_SLOW_CALL.
+func AtlasBreakerSlowCall() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_BREAKER_SLOW_CALL", 5*time.Second)
+}
+
+// AtlasBreakerOpenFor returns how long the breaker stays open before probing. Env: GAMEHUB_ATLAS_BREAKER_OPEN_FOR.
+func AtlasBreakerOpenFor() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_BREAKER_OPEN_FOR", 10*time.Second)
+}
+
+// AtlasBreakerProbes returns how many probe requests half-open lets through. Env: GAMEHUB_ATLAS_BREAKER_PROBES.
+func AtlasBreakerProbes() int {
+	return envInt("GAMEHUB_ATLAS_BREAKER_PROBES", 2)
+}
+
+// AtlasRetryMaxAttempts returns total attempts per Atlas call incl. the first. Env: GAMEHUB_ATLAS_RETRY_MAX_ATTEMPTS.
+func AtlasRetryMaxAttempts() int {
+	return envInt("GAMEHUB_ATLAS_RETRY_MAX_ATTEMPTS", 3)
+}
+
+// AtlasRetryBaseBackoff returns the wait before the first retry; doubles per retry. Env: GAMEHUB_ATLAS_RETRY_BASE_BACKOFF.
+func AtlasRetryBaseBackoff() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_RETRY_BASE_BACKOFF", 100*time.Millisecond)
+}
+
+// AtlasRetryMaxBackoff returns the cap for a single retry wait. Env: GAMEHUB_ATLAS_RETRY_MAX_BACKOFF.
+func AtlasRetryMaxBackoff() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_RETRY_MAX_BACKOFF", 2*time.Second)
+}
+
+// AtlasRetryBudget returns the max total time per Atlas call incl. retries. Env: GAMEHUB_ATLAS_RETRY_BUDGET.
+func AtlasRetryBudget() time.Duration {
+	return envDuration("GAMEHUB_ATLAS_RETRY_BUDGET", 5*time.Second)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/live"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// sseWriteTimeout bounds each write to an SSE client, so a client that stops
+// reading cannot hold its handler forever.
+const sseWriteTimeout = 10 * time.Second
+
+// liveSnapshot is the full live state sent when a stream starts.
+type liveSnapshot struct {
+	Seq           uint64 `json:"seq"`
+	SeriesIDs     []int  `json:"series_ids"`
+	TournamentIDs []int  `json:"tournament_ids"`
+	TeamIDs       []int  `json:"team_ids"`
+	PlayerIDs     []int  `json:"player_ids"`
+}
+
+func newLiveSnapshot(lc live.LiveContext, seq uint64) liveSnapshot {
+	nonNil := func(ids []int) []int {
+		if ids == nil {
+			return []int{}
+		}
+		return ids
+	}
+	return liveSnapshot{
+		Seq:           seq,
+		SeriesIDs:     nonNil(lc.SeriesIDs),
+		TournamentIDs: nonNil(lc.TournamentIDs),
+		TeamIDs:       nonNil(lc.TeamIDs),
+		PlayerIDs:     nonNil(lc.PlayerIDs),
+	}
+}
+
+// EventsLive streams live changes as Server-Sent Events. A new stream starts
+// with a "snapshot" event; a reconnect with Last-Event-ID gets the events it
+// missed instead, or a snapshot if they are no longer kept. Each change is an
+// event named after its type (series.live, team.left, ...) with the sequence
+// number as id. A comment is sent every GAMEHUB_SSE_HEARTBEAT; a client more
+// than GAMEHUB_SSE_BUFFER events behind is sent "dropped", with the id of the
+// last event it got so a reconnect resumes from there, and disconnected.
+func (h *Handler) EventsLive(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.Live.GetLiveContext(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	feed := h.Live.Events()
+	buffer := config.SSEBuffer()
+
+	var sub *live.Subscription
+	var snapshot *liveSnapshot
+	var last uint64 // id of the last event the client has
+	if lastID, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		s, ok := feed.Subscribe(lastID, buffer)
+		if ok {
+			sub, last = s, lastID
+		} else {
+			s.Cancel()
+		}
+	}
+	if sub == nil {
+		lc, seq, _ := feed.Current()
+		sub, _ = feed.Subscribe(seq, buffer)
+		snap := newLiveSnapshot(lc, seq)
+		snapshot, last = &snap, seq
+	}
+	defer sub.Cancel()
+
+	metrics.SSEClients.Add(1)
+	defer metrics.SSEClients.Add(-1)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	sw := &sseWriter{w: w, rc: http.NewResponseController(w)}
+
+	if snapshot != nil {
+		if err := sw.event(snapshot.Seq, "snapshot", snapshot); err != nil {
+			return
+		}
+	} else if err := sw.comment("resumed"); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(config.SSEHeartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					_ = sw.event(last, "dropped", map[string]string{"reason": "client too slow"})
+				}
+				return
+			}
+			if err := sw.event(e.Seq, string(e.Type), e); err != nil {
+				return
+			}
+			last = e.Seq
+		case <-heartbeat.C:
+			if err := sw.comment("heartbeat"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sseWriter writes Server-Sent Events and flushes each one.
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (s *sseWriter) event(id uint64, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", id, name, data))
+}
+
+func (s *sseWriter) comment(text string) error {
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *sseWriter) write(msg string) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		log.Printf("sse write: %v", err)
+		return err
+	}
+	if err := s.rc.Flush(); err != nil {
+		log.Printf("sse flush: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/live"
+)
+
+type sseEvent struct {
+	id, name, data string
+	comment        string
+}
+
+// sseStream reads events from an /events/live response.
+type sseStream struct {
+	t    *testing.T
+	resp *http.Response
+	r    *bufio.Reader
+}
+
+func openSSE(t *testing.T, url, lastEventID string) *sseStream {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url+"/events/live", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	return &sseStream{t: t, resp: resp, r: bufio.NewReader(resp.Body)}
+}
+
+// next returns the next event or comment block.
+func (s *sseStream) next() sseEvent {
+	s.t.Helper()
+	var e sseEvent
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			s.t.Fatalf("read: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return e
+		case strings.HasPrefix(line, ": "):
+			e.comment = line[2:]
+		case strings.HasPrefix(line, "id: "):
+			e.id = line[4:]
+		case strings.HasPrefix(line, "event: "):
+			e.name = line[7:]
+		case strings.HasPrefix(line, "data: "):
+			e.data = line[6:]
+		}
+	}
+}
+
+func newEventsServer(t *testing.T) (*httptest.Server, *live.Feed) {
+	t.Helper()
+	h := newEventsHandler(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/live", h.EventsLive)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, h.Live.Events()
+}
+
+func newEventsHandler(t *testing.T) *Handler {
+	t.Helper()
+	srv := newFakeAtlas(t)
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	return New(client, live.NewService(client, time.Minute))
+}
+
+// stalledWriter is a ResponseWriter whose writes block until release is
+// closed, like a client that stopped reading.
+type stalledWriter struct {
+	header  http.Header
+	writing chan struct{} // closed on the first write
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func newStalledWriter() *stalledWriter {
+	return &stalledWriter{header: make(http.Header), writing: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (w *stalledWriter) Header() http.Header { return w.header }
+func (w *stalledWriter) WriteHeader(int)     {}
+func (w *stalledWriter) Flush()              {}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.writing) })
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestEventsLive_SnapshotThenChanges(t *testing.T) {
+	ts, feed := newEventsServer(t)
+	s := openSSE(t, ts.URL, "")
+
+	e := s.next()
+	if e.name != "snapshot" || e.id != "0" {
+		t.Fatalf("first event = %+v, want snapshot with id 0", e)
+	}
+	var snap liveSnapshot
+	if err := json.Unmarshal([]byte(e.data), &snap); err != nil {
+		t.Fatal(err)
+	}
+	if !equalInts(snap.SeriesIDs, []int{10, 11}) || len(snap.PlayerIDs) != 8 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+
+	lc, _, _ := feed.Current()
+	lc.SeriesIDs = []int{10}
+	feed.Update(lc)
+	e = s.next()
+	if e.name != "series.ended" || e.id != "1" {
+		t.Fatalf("event = %+v, want series.ended id 1", e)
+	}
+	var ev live.Event
+	if err := json.Unmarshal([]byte(e.data), &ev); err != nil || ev.ID != 11 || ev.Seq != 1 {
+		t.Errorf("event data = %s (%v)", e.data, err)
+	}
+}
+
+func TestEventsLive_ResumeFromLastEventID(t *testing.T) {
+	ts, feed := newEventsServer(t)
+	s := openSSE(t, ts.URL, "")
+	s.next() // snapshot
+
+	lc, _, _ := feed.Current()
+	feed.Update(live.LiveContext{SeriesIDs: []int{10}, TeamIDs: lc.TeamIDs, PlayerIDs: lc.PlayerIDs})
+	feed.Update(live.LiveContext{SeriesIDs: []int{10, 12}, TeamIDs: lc.TeamIDs, PlayerIDs: lc.PlayerIDs})
+
+	r := openSSE(t, ts.URL, "1")
+	if e := r.next(); e.comment != "resumed" {
+		t.Fatalf("first block = %+v, want resumed comment", e)
+	}
+	if e := r.next(); e.name != "series.live" || e.id != "2" {
+		t.Errorf("replayed = %+v, want series.live id 2", e)
+	}
+
+	// Not known to this process (e.g. issued before a restart): start over.
+	if e := openSSE(t, ts.URL, "99").next(); e.name != "snapshot" || e.id != "2" {
+		t.Errorf("unknown Last-Event-ID: first event = %+v, want snapshot id 2", e)
+	}
+}
+
+func TestEventsLive_SlowClientDroppedThenResumes(t *testing.T) {
+	t.Setenv("GAMEHUB_SSE_BUFFER", "2")
+	h := newEventsHandler(t)
+	feed := h.Live.Events()
+
+	w := newStalledWriter()
+	req := httptest.NewRequest(http.MethodGet, "/events/live", nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.EventsLive(w, req)
+	}()
+	<-w.writing // stuck writing the snapshot
+
+	lc, _, _ := feed.Current()
+	for _, ids := range [][]int{{10}, {}, {10}, {10, 11}} {
+		lc.SeriesIDs = ids
+		feed.Update(lc) // seq 1..4; the third overflows the buffer
+	}
+	close(w.release)
+	<-done
+
+	s := &sseStream{t: t, r: bufio.NewReader(&w.buf)}
+	var got []string
+	for {
+		e := s.next()
+		got = append(got, e.name+"#"+e.id)
+		if e.name == "dropped" {
+			break
+		}
+	}
+	if strings.Join(got, " ") != "snapshot#0 series.ended#1 series.ended#2 dropped#2" {
+		t.Fatalf("stream = %v, want snapshot, the 2 buffered events, then dropped with id 2", got)
+	}
+
+	// Reconnecting with the dropped event's id picks up the events not sent.
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events/live", h.EventsLive)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	r := openSSE(t, ts.URL, "2")
+	if e := r.next(); e.comment != "resumed" {
+		t.Fatalf("first block = %+v, want resumed comment", e)
+	}
+	for _, want := range []string{"3", "4"} {
+		if e := r.next(); e.name != "series.live" || e.id != want {
+			t.Errorf("replayed = %+v, want series.live id %s", e, want)
+		}
+	}
+}
+
+func TestEventsLive_Heartbeat(t *testing.T) {
+	t.Setenv("GAMEHUB_SSE_HEARTBEAT", "10ms")
+	ts, _ := newEventsServer(t)
+	s := openSSE(t, ts.URL, "")
+	s.next() // snapshot
+	if e := s.next(); e.comment != "heartbeat" {
+		t.Errorf("got %+v, want heartbeat comment", e)
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/atlas/atlastest"
+	"github.com/aaron/gamehub/internal/live"
+)
+
+// newFakeAtlas returns a fake Atlas with two live series (rosters 1+2, 3+4,
+// tournaments 50 and 51) and one finished series (rosters 5+6, tournament 52).
+func newFakeAtlas(t *testing.T) *atlastest.Server {
+	t.Helper()
+	srv := atlastest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddSeries(
+		atlas.Series{ID: 10, Title: "A vs B", Lifecycle: "live", Tournament: atlas.Ref{ID: 50}, Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 1}}, {Roster: atlas.Ref{ID: 2}}}},
+		atlas.Series{ID: 11, Title: "C vs D", Lifecycle: "live", Tournament: atlas.Ref{ID: 51}, Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 3}}, {Roster: atlas.Ref{ID: 4}}}},
+		atlas.Series{ID: 12, Title: "E vs F", Lifecycle: "over", Tournament: atlas.Ref{ID: 52}, Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 5}}, {Roster: atlas.Ref{ID: 6}}}},
+	)
+	srv.AddTournaments(atlas.Tournament{ID: 50}, atlas.Tournament{ID: 51}, atlas.Tournament{ID: 52})
+	srv.AddMatches(
+		atlas.Match{ID: 202, Order: 2, Series: atlas.Ref{ID: 10}},
+		atlas.Match{ID: 201, Order: 1, Series: atlas.Ref{ID: 10}},
+		atlas.Match{ID: 203, Order: 1, Series: atlas.Ref{ID: 11}},
+		atlas.Match{ID: 204, Order: 1, Series: atlas.Ref{ID: 12}},
+	)
+	for r := 1; r <= 6; r++ {
+		srv.AddRosters(atlas.Roster{
+			ID:     r,
+			Team:   atlas.Ref{ID: 100 + r},
+			LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 1000 + 2*r}, {ID: 1001 + 2*r}}},
+		})
+		srv.AddTeams(atlas.Team{ID: 100 + r, Name: "team"})
+		srv.AddPlayers(atlas.Player{ID: 1000 + 2*r, NickName: "p"}, atlas.Player{ID: 1001 + 2*r, NickName: "q"})
+	}
+	return srv
+}
+
+func newFakeMux(t *testing.T, srv *atlastest.Server) *http.ServeMux {
+	t.Helper()
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	h := New(client, live.NewService(client, time.Minute))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /series/live", h.SeriesLive)
+	mux.HandleFunc("GET /players/live", h.PlayersLive)
+	mux.HandleFunc("GET /teams/live", h.TeamsLive)
+	mux.HandleFunc("GET /tournaments/live", h.TournamentsLive)
+	mux.HandleFunc("GET /series/live/{id}/matches", h.SeriesLiveMatches)
+	mux.HandleFunc("GET /events/live", h.EventsLive)
+	mux.HandleFunc("GET /ws", h.WebSocket)
+	return mux
+}
+
+func getIDs(t *testing.T, mux http.Handler, path string) []int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: want 200, got %d: %s", path, rec.Code, rec.Body.String())
+	}
+	var items []struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatalf("%s: invalid JSON array: %v", path, err)
+	}
+	ids := make([]int, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLiveEndpoints_FakeAtlas(t *testing.T) {
+	mux := newFakeMux(t, newFakeAtlas(t))
+
+	if got, want := getIDs(t, mux, "/series/live"), []int{10, 11}; !equalInts(got, want) {
+		t.Errorf("/series/live ids = %v, want %v", got, want)
+	}
+	if got, want := getIDs(t, mux, "/teams/live"), []int{101, 102, 103, 104}; !equalInts(got, want) {
+		t.Errorf("/teams/live ids = %v, want %v", got, want)
+	}
+	if got, want := getIDs(t, mux, "/players/live"), []int{1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009}; !equalInts(got, want) {
+		t.Errorf("/players/live ids = %v, want %v", got, want)
+	}
+}
+
+func TestTournamentsLive_FakeAtlas(t *testing.T) {
+	mux := newFakeMux(t, newFakeAtlas(t))
+	if got, want := getIDs(t, mux, "/tournaments/live"), []int{50, 51}; !equalInts(got, want) {
+		t.Errorf("/tournaments/live ids = %v, want %v", got, want)
+	}
+}
+
+func TestSeriesLiveMatches_FakeAtlas(t *testing.T) {
+	mux := newFakeMux(t, newFakeAtlas(t))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/series/live/10/matches", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var matches []atlas.Match
+	if err := json.Unmarshal(rec.Body.Bytes(), &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].ID != 201 || matches[1].ID != 202 {
+		t.Errorf("matches of series 10 = %+v, want 201 then 202", matches)
+	}
+
+	for path, want := range map[string]int{
+		"/series/live/12/matches":  http.StatusNotFound, // over, not live
+		"/series/live/abc/matches": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: want %d, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+func TestLiveEndpoints_FakeAtlasRateLimited(t *testing.T) {
+	srv := newFakeAtlas(t)
+	mux := newFakeMux(t, srv)
+	// More 429s than the default retry policy will absorb.
+	srv.Inject(atlastest.Fault{Path: "/series", Status: http.StatusTooManyRequests, RetryAfterMs: 1, Times: 10})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/series/live", nil)
+	ctx, cancel := context.WithTimeout(req.Context(), 5*time.Second)
+	defer cancel()
+	mux.ServeHTTP(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("want 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestLiveEndpoints_FakeAtlasServesStaleOnError(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", "0s")
+	t.Setenv("GAMEHUB_LIVE_STALE_IF_ERROR", "1m")
+	srv := newFakeAtlas(t)
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	h := New(client, live.NewService(client, time.Millisecond))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /teams/live", h.TeamsLive)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/teams/live", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-GameHub-Stale") != "" {
+		t.Fatalf("fresh: got %d, stale=%q", rec.Code, rec.Header().Get("X-GameHub-Stale"))
+	}
+	if rec.Header().Get("X-GameHub-Data-Age") != "0" {
+		t.Errorf("fresh: X-GameHub-Data-Age = %q, want 0", rec.Header().Get("X-GameHub-Data-Age"))
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	srv.Inject(atlastest.Fault{Path: "/series", Status: http.StatusNotFound})
+	if got, want := getIDs(t, mux, "/teams/live"), []int{101, 102, 103, 104}; !equalInts(got, want) {
+		t.Errorf("stale /teams/live ids = %v, want %v", got, want)
+	}
+	rec = httptest.NewRecorder()
+	srv.Inject(atlastest.Fault{Path: "/series", Status: http.StatusNotFound})
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/teams/live", nil))
+	if rec.Header().Get("X-GameHub-Stale") != "true" {
+		t.Errorf("X-GameHub-Stale = %q after a failed load, want true", rec.Header().Get("X-GameHub-Stale"))
+	}
+	if rec.Header().Get("X-GameHub-Data-Age") == "" {
+		t.Error("missing X-GameHub-Data-Age")
+	}
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/aaron/gamehub/internal/atlas"
 	"github.com/aaron/gamehub/internal/live"
@@ -29,13 +34,8 @@ func Health(w http.ResponseWriter, r *http.Request) {
 
 // SeriesLive returns currently live/ongoing series.
 func (h *Handler) SeriesLive(w http.ResponseWriter, r *http.Request) {
-	params := map[string]string{"filter": "lifecycle=live"}
-	body, _, err := h.Atlas.GetSeriesAll(r.Context(), params)
-	if err != nil {
-		writeError(w, err)
-		return
-	}
-	writeJSON(w, body)
+	q := atlas.NewQuery().Eq("lifecycle", "live")
+	writeJSONArray(w, h.Atlas.Items(r.Context(), "/series", q.Params()))
 }
 
 // PlayersLive returns players currently playing in live series.
@@ -45,37 +45,78 @@ func (h *Handler) PlayersLive(w http.ResponseWriter, r *http.Request) {
 		writeError(w, err)
 		return
 	}
+	setFreshness(w, liveCtx)
 	if len(liveCtx.PlayerIDs) == 0 {
 		writeJSON(w, []byte("[]"))
 		return
 	}
-	params := map[string]string{"filter": atlas.FilterIDIn(liveCtx.PlayerIDs)}
-	body, _, err := h.Atlas.GetPlayersAll(r.Context(), params)
+	writeJSONArray(w, h.Atlas.ItemsByIDs(r.Context(), "/players", liveCtx.PlayerIDs, nil))
+}
+
+// TeamsLive returns teams currently playing in live series.
+func (h *Handler) TeamsLive(w http.ResponseWriter, r *http.Request) {
+	liveCtx, err := h.Live.GetLiveContext(r.Context())
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeJSON(w, body)
+	setFreshness(w, liveCtx)
+	if len(liveCtx.TeamIDs) == 0 {
+		writeJSON(w, []byte("[]"))
+		return
+	}
+	writeJSONArray(w, h.Atlas.ItemsByIDs(r.Context(), "/teams", liveCtx.TeamIDs, nil))
 }
 
-// TeamsLive returns teams currently playing in live series.
-func (h *Handler) TeamsLive(w http.ResponseWriter, r *http.Request) {
+// TournamentsLive returns tournaments with at least one live series.
+func (h *Handler) TournamentsLive(w http.ResponseWriter, r *http.Request) {
 	liveCtx, err := h.Live.GetLiveContext(r.Context())
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	if len(liveCtx.TeamIDs) == 0 {
+	setFreshness(w, liveCtx)
+	if len(liveCtx.TournamentIDs) == 0 {
 		writeJSON(w, []byte("[]"))
 		return
 	}
-	params := map[string]string{"filter": atlas.FilterIDIn(liveCtx.TeamIDs)}
-	body, _, err := h.Atlas.GetTeamsAll(r.Context(), params)
+	writeJSONArray(w, h.Atlas.ItemsByIDs(r.Context(), "/tournaments", liveCtx.TournamentIDs, nil))
+}
+
+// SeriesLiveMatches returns the matches of live series {id}, in play order.
+// A series that is not live is 404.
+func (h *Handler) SeriesLiveMatches(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid series id", http.StatusBadRequest)
+		return
+	}
+	liveCtx, err := h.Live.GetLiveContext(r.Context())
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-	writeJSON(w, body)
+	setFreshness(w, liveCtx)
+	if !liveCtx.HasSeries(id) {
+		http.Error(w, "series not live", http.StatusNotFound)
+		return
+	}
+	q := atlas.NewQuery().Eq("series.id", id).OrderBy("order", atlas.Asc)
+	writeJSONArray(w, h.Atlas.Items(r.Context(), "/matches", q.Params()))
+}
+
+// setFreshness sets the age of the live context a response is built from
+// (X-GameHub-Data-Age, seconds) and marks it X-GameHub-Stale when it was served
+// past the cache TTL, and X-GameHub-Possibly-Inconsistent when the live series
+// list moved while it was paginated.
+func setFreshness(w http.ResponseWriter, liveCtx live.LiveContext) {
+	w.Header().Set("X-GameHub-Data-Age", strconv.Itoa(int(liveCtx.Age()/time.Second)))
+	if liveCtx.Stale {
+		w.Header().Set("X-GameHub-Stale", "true")
+	}
+	if liveCtx.PossiblyInconsistent {
+		w.Header().Set("X-GameHub-Possibly-Inconsistent", "true")
+	}
 }
 
 func writeJSON(w http.ResponseWriter, body []byte) {
@@ -86,11 +127,87 @@ func writeJSON(w http.ResponseWriter, body []byte) {
 	}
 }
 
+// writeJSONArray streams items as a JSON array as pages arrive from Atlas.
+// An error before the first item is written as a normal error response; after
+// that the status is already sent, so the response is cut short and logged.
+func writeJSONArray(w http.ResponseWriter, items iter.Seq2[json.RawMessage, error]) {
+	started := false
+	for item, err := range items {
+		if err != nil {
+			if !started {
+				writeError(w, err)
+				return
+			}
+			log.Printf("stream response: %v", err)
+			return
+		}
+		sep := []byte(",")
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			sep = []byte("[")
+			started = true
+		}
+		if _, err := w.Write(sep); err != nil {
+			log.Printf("write response: %v", err)
+			return
+		}
+		if _, err := w.Write(item); err != nil {
+			log.Printf("write response: %v", err)
+			return
+		}
+	}
+	if !started {
+		writeJSON(w, []byte("[]"))
+		return
+	}
+	if _, err := w.Write([]byte("]")); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+// writeError maps an Atlas failure to a status and a generic message. The
+// error itself, which may carry the raw Atlas body, is only logged. A
+// cancelled request means the client went away: nothing is written or logged.
 func writeError(w http.ResponseWriter, err error) {
-	if rlErr, ok := err.(*atlas.ErrRateLimited); ok {
-		w.Header().Set("Retry-After", fmt.Sprintf("%d", rlErr.RetryAfterMs))
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	var rlErr *atlas.ErrRateLimited
+	if errors.As(err, &rlErr) {
+		w.Header().Set("Retry-After", retryAfter(time.Duration(rlErr.RetryAfterMs)*time.Millisecond))
 		http.Error(w, "rate limited", http.StatusTooManyRequests)
 		return
 	}
-	http.Error(w, err.Error(), http.StatusInternalServerError)
+	log.Printf("atlas request failed: %v", err)
+	var openErr *atlas.ErrCircuitOpen
+	if errors.As(err, &openErr) {
+		w.Header().Set("Retry-After", retryAfter(openErr.RetryAfter))
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	status, msg := errorStatus(err)
+	http.Error(w, msg, status)
+}
+
+// retryAfter formats d as a Retry-After value: whole seconds, rounded up (at
+// least 1).
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(max(int((d+time.Second-1)/time.Second), 1))
+}
+
+// errorStatus returns the response status and message for err.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, atlas.ErrNotFound):
+		return http.StatusNotFound, "not found"
+	case errors.Is(err, atlas.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "upstream timeout"
+	case errors.Is(err, atlas.ErrUnavailable):
+		return http.StatusServiceUnavailable, "upstream unavailable"
+	case errors.Is(err, atlas.ErrUnauthorized), errors.Is(err, atlas.ErrBadRequest), errors.Is(err, atlas.ErrDecode):
+		return http.StatusBadGateway, "bad upstream response"
+	default:
+		return http.StatusInternalServerError, "internal error"
+	}
 }
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/live"
 )
 
 func TestHealth(t *testing.T) {
@@ -28,13 +34,130 @@ func TestHealth(t *testing.T) {
 
 func TestWriteError_RateLimited(t *testing.T) {
 	w := httptest.NewRecorder()
-	err := &atlas.ErrRateLimited{RetryAfterMs: 500}
+	err := &atlas.ErrRateLimited{RetryAfterMs: 1500}
 	writeError(w, err)
 
 	if w.Code != http.StatusTooManyRequests {
 		t.Errorf("want 429, got %d", w.Code)
 	}
-	if retry := w.Header().Get("Retry-After"); retry != "500" {
-		t.Errorf("want Retry-After: 500, got %q", retry)
+	// Retry-After is in seconds, rounded up.
+	if retry := w.Header().Get("Retry-After"); retry != "2" {
+		t.Errorf("want Retry-After: 2, got %q", retry)
+	}
+
+	w = httptest.NewRecorder()
+	writeError(w, &atlas.ErrRateLimited{RetryAfterMs: 200})
+	if retry := w.Header().Get("Retry-After"); retry != "1" {
+		t.Errorf("sub-second wait: want Retry-After: 1, got %q", retry)
+	}
+}
+
+func TestWriteError_ClientGoneWritesNothing(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, fmt.Errorf("load: %w", context.Canceled))
+	if w.Body.Len() != 0 || len(w.Header()) != 0 {
+		t.Errorf("wrote %q with headers %v for a cancelled request", w.Body.String(), w.Header())
+	}
+}
+
+func TestSeriesLive_StreamsArray(t *testing.T) {
+	atlasSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("filter"); got != "lifecycle=live" {
+			t.Errorf("filter: want lifecycle=live, got %q", got)
+		}
+		if r.URL.Query().Get("skip") == "0" {
+			_, _ = w.Write([]byte(`[{"id":1},{"id":2}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer atlasSrv.Close()
+
+	h := New(atlas.NewClientWithURL("test-secret", atlasSrv.URL), nil)
+	rec := httptest.NewRecorder()
+	h.SeriesLive(rec, httptest.NewRequest(http.MethodGet, "/series/live", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); body != `[{"id":1},{"id":2}]` {
+		t.Errorf("body: got %s", body)
+	}
+}
+
+func TestWriteJSONArray_Empty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeJSONArray(rec, func(yield func(json.RawMessage, error) bool) {})
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]" {
+		t.Errorf("want 200 [], got %d %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type: want application/json, got %q", ct)
+	}
+}
+
+func TestWriteJSONArray_ErrorBeforeFirstItem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeJSONArray(rec, func(yield func(json.RawMessage, error) bool) {
+		yield(nil, &atlas.ErrRateLimited{RetryAfterMs: 200})
+	})
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("want 429, got %d", rec.Code)
+	}
+}
+
+func TestWriteError_CircuitOpen(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, &atlas.ErrCircuitOpen{RetryAfter: 2500 * time.Millisecond})
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("want 503, got %d", w.Code)
+	}
+	if retry := w.Header().Get("Retry-After"); retry != "3" {
+		t.Errorf("want Retry-After: 3, got %q", retry)
+	}
+}
+
+func TestWriteError_MapsAtlasErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", &atlas.APIError{StatusCode: http.StatusNotFound, Body: "secret upstream detail"}, http.StatusNotFound},
+		{"unauthorized", &atlas.APIError{StatusCode: http.StatusUnauthorized, Body: "secret upstream detail"}, http.StatusBadGateway},
+		{"bad request", &atlas.APIError{StatusCode: http.StatusBadRequest, Body: "secret upstream detail"}, http.StatusBadGateway},
+		{"upstream 5xx", &atlas.APIError{StatusCode: http.StatusInternalServerError, Body: "secret upstream detail"}, http.StatusServiceUnavailable},
+		{"upstream 504", &atlas.APIError{StatusCode: http.StatusGatewayTimeout, Body: "secret upstream detail"}, http.StatusGatewayTimeout},
+		{"timeout", fmt.Errorf("%w: secret upstream detail", atlas.ErrTimeout), http.StatusGatewayTimeout},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"decode", fmt.Errorf("%w: secret upstream detail", atlas.ErrDecode), http.StatusBadGateway},
+		{"no key", atlas.ErrNoAPIKey, http.StatusBadGateway},
+		{"other", errors.New("secret upstream detail"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, tt.err)
+			if w.Code != tt.want {
+				t.Errorf("want %d, got %d", tt.want, w.Code)
+			}
+			if strings.Contains(w.Body.String(), "secret upstream detail") {
+				t.Errorf("response leaks the error detail: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetFreshness_PossiblyInconsistent(t *testing.T) {
+	w := httptest.NewRecorder()
+	setFreshness(w, live.LiveContext{LoadedAt: time.Now()})
+	if got := w.Header().Get("X-GameHub-Possibly-Inconsistent"); got != "" {
+		t.Errorf("consistent context: header = %q, want none", got)
+	}
+	w = httptest.NewRecorder()
+	setFreshness(w, live.LiveContext{LoadedAt: time.Now(), PossiblyInconsistent: true})
+	if got := w.Header().Get("X-GameHub-Possibly-Inconsistent"); got != "true" {
+		t.Errorf("header = %q, want true", got)
 	}
 }
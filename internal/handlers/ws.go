@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/live"
+	"github.com/aaron/gamehub/internal/metrics"
+	"github.com/aaron/gamehub/internal/ws"
+)
+
+// wsReadLimit bounds one client message; requests are small JSON objects.
+const wsReadLimit = 4 << 10
+
+// topicSeriesLive carries series going live and ending; team:{id} and
+// player:{id} carry one team or player joining or leaving live play.
+const topicSeriesLive = "series:live"
+
+// wsRequest is a message from a /ws client:
+//
+//	{"op": "subscribe", "topics": ["series:live", "team:101", "player:7"]}
+//	{"op": "unsubscribe", "topics": ["team:101"]}
+type wsRequest struct {
+	Op     string   `json:"op"`
+	Topics []string `json:"topics"`
+}
+
+// wsMessage is a message to a /ws client. Type is subscribed, unsubscribed,
+// snapshot (current state of Topic as of Seq), event (Data is a live.Event) or
+// error.
+type wsMessage struct {
+	Type   string   `json:"type"`
+	Topic  string   `json:"topic,omitempty"`
+	Topics []string `json:"topics,omitempty"`
+	Seq    uint64   `json:"seq,omitempty"`
+	Data   any      `json:"data,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// validTopic reports whether topic is series:live, team:{id} or player:{id}.
+func validTopic(topic string) bool {
+	if topic == topicSeriesLive {
+		return true
+	}
+	kind, id, ok := strings.Cut(topic, ":")
+	if !ok || (kind != "team" && kind != "player") {
+		return false
+	}
+	n, err := strconv.Atoi(id)
+	return err == nil && n > 0 && strconv.Itoa(n) == id
+}
+
+// eventTopic returns the topic an event is published on.
+func eventTopic(e live.Event) string {
+	switch e.Type {
+	case live.TeamJoined, live.TeamLeft:
+		return fmt.Sprintf("team:%d", e.ID)
+	case live.PlayerJoined, live.PlayerLeft:
+		return fmt.Sprintf("player:%d", e.ID)
+	default:
+		return topicSeriesLive
+	}
+}
+
+// topicSnapshot returns the current state of topic in lc.
+func topicSnapshot(topic string, lc live.LiveContext) any {
+	kind, idStr, _ := strings.Cut(topic, ":")
+	id, _ := strconv.Atoi(idStr)
+	switch kind {
+	case "team":
+		return map[string]any{"id": id, "live": slices.Contains(lc.TeamIDs, id)}
+	case "player":
+		return map[string]any{"id": id, "live": slices.Contains(lc.PlayerIDs, id)}
+	default:
+		return map[string]any{"series_ids": newLiveSnapshot(lc, 0).SeriesIDs}
+	}
+}
+
+// WebSocket serves GET /ws. Clients subscribe to topics (series:live,
+// team:{id}, player:{id}) and get a snapshot of each from the live cache,
+// then an event whenever it changes. The server pings every
+// GAMEHUB_WS_PING_INTERVAL and drops clients silent for two intervals; a
+// connection may hold GAMEHUB_WS_MAX_SUBSCRIPTIONS topics and is closed if it
+// falls GAMEHUB_WS_BUFFER events behind. Browsers may connect from the same
+// host or from GAMEHUB_WS_ALLOWED_ORIGINS.
+func (h *Handler) WebSocket(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.Live.GetLiveContext(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	conn, err := ws.Upgrade(w, r, config.WSAllowedOrigins()...)
+	if err != nil {
+		log.Printf("ws upgrade: %v", err)
+		return
+	}
+	metrics.WSConnections.Add(1)
+	metrics.WSConnectionsTotal.Add(1)
+	defer metrics.WSConnections.Add(-1)
+
+	c := &wsClient{conn: conn, feed: h.Live.Events(), topics: make(map[string]uint64), limit: config.WSMaxSubscriptions()}
+	defer func() { metrics.WSSubscriptions.Add(-int64(len(c.topics))) }()
+	c.serve()
+}
+
+// wsClient is one /ws connection.
+type wsClient struct {
+	conn   *ws.Conn
+	feed   *live.Feed
+	topics map[string]uint64 // topic -> seq of the snapshot it was sent
+	limit  int
+}
+
+func (c *wsClient) serve() {
+	sub, _ := c.feed.Subscribe(c.feed.Seq(), config.WSBuffer())
+	defer sub.Cancel()
+
+	ping := config.WSPingInterval()
+	c.conn.SetReadLimit(wsReadLimit)
+	c.conn.SetReadTimeout(2 * ping)
+
+	// The reader hands requests to this goroutine, which does all the writing
+	// except pongs; closing the connection stops the reader.
+	requests := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			typ, msg, err := c.conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			metrics.WSMessagesIn.Add(1)
+			if typ != ws.Text {
+				msg = nil // only JSON text is understood
+			}
+			select {
+			case requests <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(ping)
+	defer ticker.Stop()
+	code, reason := ws.CloseGoingAway, ""
+	defer func() { _ = c.conn.Close(code, reason) }()
+	for {
+		select {
+		case msg := <-requests:
+			if err := c.handle(msg); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				metrics.WSDropped.Add(1)
+				code, reason = ws.CloseTryAgainLater, "too slow"
+				return
+			}
+			// Events up to a topic's snapshot may still be queued; the
+			// snapshot already has them.
+			topic := eventTopic(e)
+			if snap, ok := c.topics[topic]; ok && e.Seq > snap {
+				if err := c.send(wsMessage{Type: "event", Topic: topic, Seq: e.Seq, Data: e}); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := c.conn.Ping(); err != nil {
+				return
+			}
+		case err := <-readErr:
+			if !isClosedErr(err) {
+				log.Printf("ws %s: %v", c.conn.RemoteAddr(), err)
+			}
+			code = ws.CloseNormal
+			return
+		}
+	}
+}
+
+// isClosedErr reports whether err is an ordinary end of a connection.
+func isClosedErr(err error) bool {
+	var ce *ws.CloseError
+	if errors.As(err, &ce) {
+		return ce.Code == ws.CloseNormal || ce.Code == ws.CloseGoingAway
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed)
+}
+
+// handle answers one client request. A bad request gets an error message;
+// only a failed write ends the connection.
+func (c *wsClient) handle(msg []byte) error {
+	var req wsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return c.sendError("invalid request")
+	}
+	for _, t := range req.Topics {
+		if !validTopic(t) {
+			return c.sendError(fmt.Sprintf("invalid topic %q", t))
+		}
+	}
+	switch req.Op {
+	case "subscribe":
+		return c.subscribe(req.Topics)
+	case "unsubscribe":
+		var removed []string
+		for _, t := range req.Topics {
+			if _, ok := c.topics[t]; ok {
+				delete(c.topics, t)
+				removed = append(removed, t)
+			}
+		}
+		metrics.WSSubscriptions.Add(-int64(len(removed)))
+		return c.send(wsMessage{Type: "unsubscribed", Topics: req.Topics})
+	default:
+		return c.sendError(fmt.Sprintf("unknown op %q", req.Op))
+	}
+}
+
+// subscribe adds topics, all or none, and sends a snapshot of each new one.
+// Events for a topic are forwarded from after its snapshot's seq.
+func (c *wsClient) subscribe(topics []string) error {
+	var added []string
+	for _, t := range topics {
+		if _, ok := c.topics[t]; !ok && !slices.Contains(added, t) {
+			added = append(added, t)
+		}
+	}
+	if len(c.topics)+len(added) > c.limit {
+		return c.sendError(fmt.Sprintf("subscription limit %d reached", c.limit))
+	}
+	lc, seq, _ := c.feed.Current()
+	for _, t := range added {
+		c.topics[t] = seq
+	}
+	metrics.WSSubscriptions.Add(int64(len(added)))
+	if err := c.send(wsMessage{Type: "subscribed", Topics: topics}); err != nil {
+		return err
+	}
+	for _, t := range added {
+		if err := c.send(wsMessage{Type: "snapshot", Topic: t, Seq: seq, Data: topicSnapshot(t, lc)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *wsClient) sendError(msg string) error {
+	return c.send(wsMessage{Type: "error", Error: msg})
+}
+
+func (c *wsClient) send(m wsMessage) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.WriteMessage(ws.Text, data); err != nil {
+		return err
+	}
+	metrics.WSMessagesOut.Add(1)
+	return nil
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/live"
+	"github.com/aaron/gamehub/internal/metrics"
+	"github.com/aaron/gamehub/internal/ws"
+)
+
+func TestValidTopic(t *testing.T) {
+	for topic, want := range map[string]bool{
+		"series:live": true,
+		"team:101":    true,
+		"player:7":    true,
+		"team:0":      false,
+		"team:-1":     false,
+		"team:07":     false,
+		"player:x":    false,
+		"series:1":    false,
+		"games":       false,
+	} {
+		if got := validTopic(topic); got != want {
+			t.Errorf("validTopic(%q) = %v, want %v", topic, got, want)
+		}
+	}
+}
+
+// newWSServer serves the fake Atlas live endpoints behind the metrics
+// middleware, so the upgrade goes through its response recorder.
+func newWSServer(t *testing.T) (string, *live.Feed) {
+	t.Helper()
+	url, feed, _ := newStallingWSServer(t)
+	return url, feed
+}
+
+// newStallingWSServer is newWSServer with a listener whose connections can be
+// made to stop writing, like a client whose TCP window is full.
+func newStallingWSServer(t *testing.T) (string, *live.Feed, *stallListener) {
+	t.Helper()
+	srv := newFakeAtlas(t)
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	liveSvc := live.NewService(client, time.Minute)
+	h := New(client, liveSvc)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws", h.WebSocket)
+	ts := httptest.NewUnstartedServer(metrics.Middleware(mux))
+	l := &stallListener{Listener: ts.Listener}
+	ts.Listener = l
+	ts.Start()
+	t.Cleanup(ts.Close)
+	t.Cleanup(l.resume)
+	return "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws", liveSvc.Events(), l
+}
+
+type stallListener struct {
+	net.Listener
+	mu      sync.Mutex
+	gate    chan struct{} // writes wait for it to close; nil = writes flow
+	stalled chan struct{} // closed when a write first waits on gate
+	once    *sync.Once
+}
+
+func (l *stallListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &stallConn{Conn: c, l: l}, nil
+}
+
+// stall holds server writes until resume. The returned channel is closed once
+// a write is held.
+func (l *stallListener) stall() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gate, l.stalled, l.once = make(chan struct{}), make(chan struct{}), new(sync.Once)
+	return l.stalled
+}
+
+func (l *stallListener) resume() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.gate != nil {
+		close(l.gate)
+		l.gate = nil
+	}
+}
+
+type stallConn struct {
+	net.Conn
+	l *stallListener
+}
+
+func (c *stallConn) Write(p []byte) (int, error) {
+	c.l.mu.Lock()
+	gate, stalled, once := c.l.gate, c.l.stalled, c.l.once
+	c.l.mu.Unlock()
+	if gate != nil {
+		once.Do(func() { close(stalled) })
+		<-gate
+	}
+	return c.Conn.Write(p)
+}
+
+type wsTestClient struct {
+	t    *testing.T
+	conn *ws.Conn
+}
+
+func dialWS(t *testing.T, url string) *wsTestClient {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := ws.Dial(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close(ws.CloseNormal, "") })
+	return &wsTestClient{t: t, conn: conn}
+}
+
+func (c *wsTestClient) send(req wsRequest) {
+	c.t.Helper()
+	data, _ := json.Marshal(req)
+	if err := c.conn.WriteMessage(ws.Text, data); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// recv returns the next message with Data left as raw JSON.
+func (c *wsTestClient) recv() (wsMessage, json.RawMessage) {
+	c.t.Helper()
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		c.t.Fatalf("read: %v", err)
+	}
+	var m struct {
+		wsMessage
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		c.t.Fatalf("decode %s: %v", data, err)
+	}
+	return m.wsMessage, m.Data
+}
+
+func TestWebSocket_SubscribeSnapshotAndEvents(t *testing.T) {
+	url, feed := newWSServer(t)
+	before := metrics.WSConnectionsTotal.Load()
+	c := dialWS(t, url)
+	if metrics.WSConnectionsTotal.Load() != before+1 {
+		t.Error("ws_connections_total not counted")
+	}
+
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"series:live", "team:101", "player:1002"}})
+	if m, _ := c.recv(); m.Type != "subscribed" || len(m.Topics) != 3 {
+		t.Fatalf("got %+v, want subscribed to 3 topics", m)
+	}
+	want := map[string]string{
+		"series:live": `{"series_ids":[10,11]}`,
+		"team:101":    `{"id":101,"live":true}`,
+		"player:1002": `{"id":1002,"live":true}`,
+	}
+	for range 3 {
+		m, data := c.recv()
+		if m.Type != "snapshot" || string(data) != want[m.Topic] {
+			t.Errorf("got %+v %s, want snapshot %s", m, data, want[m.Topic])
+		}
+	}
+
+	// Series 10 (rosters 1 and 2: teams 101, 102) ends.
+	lc, _, _ := feed.Current()
+	feed.Update(live.LiveContext{
+		SeriesIDs: []int{11},
+		TeamIDs:   []int{103, 104},
+		PlayerIDs: lc.PlayerIDs,
+	})
+	var got []string
+	for range 2 {
+		m, data := c.recv()
+		var e live.Event
+		if err := json.Unmarshal(data, &e); err != nil {
+			t.Fatal(err)
+		}
+		if m.Type != "event" || m.Seq != e.Seq {
+			t.Errorf("got %+v", m)
+		}
+		got = append(got, m.Topic+" "+string(e.Type))
+	}
+	// Team 102 left too, but nobody subscribed to it.
+	if strings.Join(got, ", ") != "series:live series.ended, team:101 team.left" {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestWebSocket_SkipsEventsInSnapshot(t *testing.T) {
+	url, feed, l := newStallingWSServer(t)
+	c := dialWS(t, url)
+
+	// Hold the server in a write while an event is published and a subscribe
+	// arrives: the event is queued for the connection but also in the snapshot.
+	stalled := l.stall()
+	c.send(wsRequest{Op: "dance"})
+	<-stalled
+	in := metrics.WSMessagesIn.Load()
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"series:live"}})
+	for metrics.WSMessagesIn.Load() == in {
+		time.Sleep(time.Millisecond) // until the server has read it
+	}
+	lc, _, _ := feed.Current()
+	lc.SeriesIDs = []int{10}
+	feed.Update(lc) // seq 1
+	l.resume()
+
+	if m, _ := c.recv(); m.Type != "error" {
+		t.Fatalf("got %+v, want unknown op error", m)
+	}
+	if m, _ := c.recv(); m.Type != "subscribed" {
+		t.Fatalf("got %+v, want subscribed", m)
+	}
+	if m, data := c.recv(); m.Type != "snapshot" || m.Seq != 1 || string(data) != `{"series_ids":[10]}` {
+		t.Fatalf("got %+v %s, want snapshot at seq 1", m, data)
+	}
+	lc.SeriesIDs = nil
+	feed.Update(lc) // seq 2
+	if m, _ := c.recv(); m.Type != "event" || m.Seq != 2 {
+		t.Errorf("got %+v, want event seq 2 (seq 1 is in the snapshot)", m)
+	}
+}
+
+func TestWebSocket_ClosesSlowConnection(t *testing.T) {
+	t.Setenv("GAMEHUB_WS_BUFFER", "1")
+	url, feed, l := newStallingWSServer(t)
+	c := dialWS(t, url)
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"series:live"}})
+	c.recv() // subscribed
+	c.recv() // snapshot
+	dropped := metrics.WSDropped.Load()
+
+	stalled := l.stall()
+	lc, _, _ := feed.Current()
+	lc.SeriesIDs = []int{10}
+	feed.Update(lc) // seq 1: the server is stuck writing it
+	<-stalled
+	for _, ids := range [][]int{{}, {10}} {
+		lc.SeriesIDs = ids
+		feed.Update(lc) // seq 2 fills the buffer, seq 3 overflows it
+	}
+	l.resume()
+
+	for _, want := range []uint64{1, 2} {
+		if m, _ := c.recv(); m.Type != "event" || m.Seq != want {
+			t.Fatalf("got %+v, want event seq %d", m, want)
+		}
+	}
+	var ce *ws.CloseError
+	if _, _, err := c.conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != ws.CloseTryAgainLater {
+		t.Fatalf("got %v, want close 1013", err)
+	}
+	if metrics.WSDropped.Load() != dropped+1 {
+		t.Error("ws_dropped not counted")
+	}
+}
+
+func TestWebSocket_RejectsBadRequestsAndEnforcesLimit(t *testing.T) {
+	t.Setenv("GAMEHUB_WS_MAX_SUBSCRIPTIONS", "2")
+	url, _ := newWSServer(t)
+	c := dialWS(t, url)
+
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"team:abc"}})
+	if m, _ := c.recv(); m.Type != "error" || !strings.Contains(m.Error, "invalid topic") {
+		t.Errorf("got %+v, want invalid topic error", m)
+	}
+	c.send(wsRequest{Op: "dance"})
+	if m, _ := c.recv(); m.Type != "error" {
+		t.Errorf("got %+v, want unknown op error", m)
+	}
+
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"team:1", "team:2", "team:3"}})
+	if m, _ := c.recv(); m.Type != "error" || !strings.Contains(m.Error, "limit") {
+		t.Fatalf("got %+v, want subscription limit error", m)
+	}
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"team:1", "team:2"}})
+	if m, _ := c.recv(); m.Type != "subscribed" {
+		t.Fatalf("got %+v, want subscribed", m)
+	}
+	c.recv()
+	c.recv()
+	c.send(wsRequest{Op: "unsubscribe", Topics: []string{"team:1"}})
+	if m, _ := c.recv(); m.Type != "unsubscribed" {
+		t.Fatalf("got %+v, want unsubscribed", m)
+	}
+	c.send(wsRequest{Op: "subscribe", Topics: []string{"player:9"}})
+	if m, _ := c.recv(); m.Type != "subscribed" {
+		t.Errorf("got %+v, want subscribed after freeing a slot", m)
+	}
+}
+
+func TestWebSocket_PingKeepsClientAndDropsSilentOne(t *testing.T) {
+	t.Setenv("GAMEHUB_WS_PING_INTERVAL", "20ms")
+	url, _ := newWSServer(t)
+
+	// A reading client answers pings and stays connected.
+	c := dialWS(t, url)
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		c.send(wsRequest{Op: "subscribe", Topics: []string{"series:live"}})
+	}()
+	if m, _ := c.recv(); m.Type != "subscribed" {
+		t.Errorf("got %+v after pings, want subscribed", m)
+	}
+
+	// A client that never reads never pongs and is disconnected.
+	open := metrics.WSConnections.Load()
+	dialWS(t, url)
+	deadline := time.Now().Add(2 * time.Second)
+	for metrics.WSConnections.Load() > open {
+		if time.Now().After(deadline) {
+			t.Fatal("silent client not disconnected")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,53 +1,215 @@
 package live
 
 import (
+	"context"
+	"log"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
 )
 
-// LiveContext holds team and player IDs derived from live series.
+// LiveContext holds the live series and the tournament, team and player IDs
+// derived from them.
 type LiveContext struct {
-	TeamIDs   []int
-	PlayerIDs []int
+	SeriesIDs     []int
+	TournamentIDs []int
+	TeamIDs       []int
+	PlayerIDs     []int
+
+	LoadedAt time.Time // when the cache stored it
+	Stale    bool      // served past the cache TTL
+
+	// PossiblyInconsistent is set when the live series list may miss or
+	// repeat a series because it moved while being paginated.
+	PossiblyInconsistent bool
+}
+
+// HasSeries reports whether id is one of the live series.
+func (c LiveContext) HasSeries(id int) bool {
+	return slices.Contains(c.SeriesIDs, id)
 }
 
-// Cache is a TTL cache for LiveContext.
+// Age returns how old the data is.
+func (c LiveContext) Age() time.Duration {
+	if c.LoadedAt.IsZero() {
+		return 0
+	}
+	return time.Since(c.LoadedAt)
+}
+
+// Cache is a TTL cache for LiveContext. Reads of the current entry are
+// lock-free; concurrent misses share one load, which runs outside the lock with
+// its own deadline. Each caller waits on its own context; the load is cancelled
+// once every caller waiting for it is gone.
+//
+// Past the TTL an entry is still served, marked stale, for the
+// stale-while-revalidate window while one load runs in the background, and
+// for the stale-if-error window when a load fails.
 type Cache struct {
-	mu       sync.RWMutex
-	entry    *cacheEntry
-	ttl      time.Duration
-	loadFunc func() (LiveContext, error)
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	loadTimeout          time.Duration
+	loadFunc             func(context.Context) (LiveContext, error)
+	onStore              func(LiveContext)                     // called with each new entry, in store order
+	background           func(context.Context) context.Context // applied to revalidation loads; nil = none
+
+	entry atomic.Pointer[LiveContext] // nil until the first load succeeds
+
+	mu   sync.Mutex                // serializes starting, joining and finishing loads
+	load atomic.Pointer[cacheLoad] // in-flight load, nil if none; set under mu
 }
 
-type cacheEntry struct {
-	ctx   LiveContext
-	until time.Time
+// cacheLoad is a load shared by the callers waiting for it.
+type cacheLoad struct {
+	done       chan struct{} // closed when ctx and err are set
+	ctx        LiveContext
+	err        error
+	waiters    int  // guarded by Cache.mu
+	background bool // revalidating a stale entry; not cancelled when waiters leave
+	cancel     context.CancelFunc
 }
 
-// NewCache creates a cache with the given TTL.
-func NewCache(ttl time.Duration, load func() (LiveContext, error)) *Cache {
-	return &Cache{ttl: ttl, loadFunc: load}
+// NewCache creates a cache with the given TTL. Loads are bounded by
+// GAMEHUB_LIVE_LOAD_TIMEOUT; the stale windows come from
+// GAMEHUB_LIVE_STALE_WHILE_REVALIDATE and GAMEHUB_LIVE_STALE_IF_ERROR.
+func NewCache(ttl time.Duration, load func(context.Context) (LiveContext, error)) *Cache {
+	return &Cache{
+		ttl:                  ttl,
+		staleWhileRevalidate: config.LiveStaleWhileRevalidate(),
+		staleIfError:         config.LiveStaleIfError(),
+		loadTimeout:          config.LiveLoadTimeout(),
+		loadFunc:             load,
+	}
+}
+
+// Get returns the cached LiveContext if valid. Within the stale-while-revalidate
+// window it returns the stale entry and starts a background load; otherwise it
+// waits for a shared load, falling back to a stale entry within the
+// stale-if-error window if the load fails. It returns ctx.Err() if ctx is done
+// first.
+func (c *Cache) Get(ctx context.Context) (LiveContext, error) {
+	return c.get(ctx, false)
+}
+
+// Refresh loads the LiveContext even if the cached one is still valid, joining
+// a load already in flight, and stores the result on success. It never serves
+// stale data.
+func (c *Cache) Refresh(ctx context.Context) (LiveContext, error) {
+	return c.get(ctx, true)
 }
 
-// Get returns the cached LiveContext if valid, otherwise loads and caches.
-func (c *Cache) Get() (LiveContext, error) {
-	c.mu.RLock()
-	if c.entry != nil && time.Now().Before(c.entry.until) {
-		ctx := c.entry.ctx
-		c.mu.RUnlock()
-		return ctx, nil
+func (c *Cache) get(ctx context.Context, force bool) (LiveContext, error) {
+	if e := c.entry.Load(); !force && e != nil {
+		age := time.Since(e.LoadedAt)
+		if age < c.ttl {
+			return *e, nil
+		}
+		if age < c.ttl+c.staleWhileRevalidate {
+			c.revalidate(ctx)
+			return c.stale(*e), nil
+		}
 	}
-	c.mu.RUnlock()
 
+	c.mu.Lock()
+	l := c.load.Load()
+	if l == nil {
+		l = c.startLocked(ctx)
+	}
+	l.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-l.done:
+		if l.err != nil && !force {
+			if lc, ok := c.staleOnError(); ok {
+				log.Printf("live: load failed, serving data from %v ago: %v", lc.Age().Round(time.Millisecond), l.err)
+				return lc, nil
+			}
+		}
+		return l.ctx, l.err
+	case <-ctx.Done():
+		c.leave(l)
+		return LiveContext{}, ctx.Err()
+	}
+}
+
+// revalidate starts a background load unless one is already in flight.
+func (c *Cache) revalidate(ctx context.Context) {
+	if c.load.Load() != nil {
+		return
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if c.entry != nil && time.Now().Before(c.entry.until) {
-		return c.entry.ctx, nil
+	if c.load.Load() == nil {
+		if c.background != nil {
+			ctx = c.background(ctx)
+		}
+		c.startLocked(ctx).background = true
+	}
+}
+
+// startLocked starts a load. It keeps the caller's values (so a miss loads
+// with the priority of the caller that has to wait for it), not its
+// cancellation: other callers may join.
+func (c *Cache) startLocked(ctx context.Context) *cacheLoad {
+	loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.loadTimeout)
+	l := &cacheLoad{done: make(chan struct{}), cancel: cancel}
+	c.load.Store(l)
+	go c.run(loadCtx, l)
+	return l
+}
+
+// staleOnError returns the cached entry if it is within the stale-if-error window.
+func (c *Cache) staleOnError() (LiveContext, bool) {
+	e := c.entry.Load()
+	if e == nil || time.Since(e.LoadedAt) >= c.ttl+c.staleIfError {
+		return LiveContext{}, false
+	}
+	return c.stale(*e), true
+}
+
+func (c *Cache) stale(lc LiveContext) LiveContext {
+	metrics.LiveStaleServed.Add(1)
+	lc.Stale = true
+	return lc
+}
+
+// leave drops a waiter from l and cancels l when it was the last one.
+func (c *Cache) leave(l *cacheLoad) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l.waiters--
+	if l.waiters == 0 && !l.background && c.load.Load() == l {
+		c.load.Store(nil)
+		l.cancel()
+	}
+}
+
+func (c *Cache) run(ctx context.Context, l *cacheLoad) {
+	lc, err := c.loadFunc(ctx)
+	l.cancel()
+	if err == nil {
+		lc.LoadedAt, lc.Stale = time.Now(), false
+	}
+	c.mu.Lock()
+	// An abandoned load may still finish; keep its result unless a newer load
+	// has taken over.
+	cur := c.load.Load()
+	if err == nil && (cur == l || cur == nil) {
+		c.entry.Store(&lc)
+		if c.onStore != nil {
+			c.onStore(lc)
+		}
 	}
-	ctx, err := c.loadFunc()
-	if err != nil {
-		return LiveContext{}, err
+	if cur == l {
+		c.load.Store(nil)
 	}
-	c.entry = &cacheEntry{ctx: ctx, until: time.Now().Add(c.ttl)}
-	return ctx, nil
+	l.ctx, l.err = lc, err
+	c.mu.Unlock()
+	close(l.done)
 }
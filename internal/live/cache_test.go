@@ -0,0 +1,300 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_CoalescesLoads(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		loads.Add(1)
+		<-release
+		return LiveContext{SeriesIDs: []int{1}}, nil
+	})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			lc, err := c.Get(context.Background())
+			if err != nil || !lc.HasSeries(1) {
+				t.Errorf("Get = %v, %v", lc, err)
+			}
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loads = %d, want 1", n)
+	}
+	if _, err := c.Get(context.Background()); err != nil || loads.Load() != 1 {
+		t.Errorf("cached Get: err=%v loads=%d", err, loads.Load())
+	}
+}
+
+func TestCache_WaiterCancelDoesNotCancelLoad(t *testing.T) {
+	release := make(chan struct{})
+	loadErr := make(chan error, 1)
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		select {
+		case <-release:
+			return LiveContext{SeriesIDs: []int{7}}, nil
+		case <-ctx.Done():
+			loadErr <- ctx.Err()
+			return LiveContext{}, ctx.Err()
+		}
+	})
+
+	patient := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background())
+		patient <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled waiter: err = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	if err := <-patient; err != nil {
+		t.Errorf("patient waiter: %v", err)
+	}
+	select {
+	case err := <-loadErr:
+		t.Errorf("load cancelled while a waiter remained: %v", err)
+	default:
+	}
+}
+
+func TestCache_LastWaiterCancelsLoad(t *testing.T) {
+	loadErr := make(chan error, 1)
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		<-ctx.Done()
+		loadErr <- ctx.Err()
+		return LiveContext{}, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case err := <-loadErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("load ctx err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("load not cancelled after last waiter left")
+	}
+}
+
+func TestCache_LoadTimeout(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_LOAD_TIMEOUT", "20ms")
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		<-ctx.Done()
+		return LiveContext{}, ctx.Err()
+	})
+	if _, err := c.Get(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCache_LoadKeepsCallerValues(t *testing.T) {
+	type key struct{}
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		if ctx.Value(key{}) != "v" {
+			t.Error("load context lost caller values")
+		}
+		return LiveContext{}, nil
+	})
+	if _, err := c.Get(context.WithValue(context.Background(), key{}, "v")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", "1m")
+	var loads atomic.Int32
+	release := make(chan struct{})
+	c := NewCache(time.Millisecond, func(ctx context.Context) (LiveContext, error) {
+		if loads.Add(1) > 1 {
+			<-release
+		}
+		return LiveContext{SeriesIDs: []int{int(loads.Load())}}, nil
+	})
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Expired: served stale without waiting, one background load.
+	for range 3 {
+		lc, err := c.Get(context.Background())
+		if err != nil || !lc.Stale || !lc.HasSeries(1) {
+			t.Fatalf("Get = %+v, %v; want stale series 1", lc, err)
+		}
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if c.entry.Load().HasSeries(2) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("background load not stored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("loads = %d, want 2", n)
+	}
+}
+
+func TestCache_StaleIfError(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", "0s")
+	t.Setenv("GAMEHUB_LIVE_STALE_IF_ERROR", "1m")
+	fail := errors.New("boom")
+	var failing atomic.Bool
+	c := NewCache(time.Millisecond, func(ctx context.Context) (LiveContext, error) {
+		if failing.Load() {
+			return LiveContext{}, fail
+		}
+		return LiveContext{SeriesIDs: []int{1}}, nil
+	})
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	failing.Store(true)
+
+	lc, err := c.Get(context.Background())
+	if err != nil || !lc.Stale || !lc.HasSeries(1) || lc.Age() <= 0 {
+		t.Errorf("Get = %+v, %v; want stale series 1", lc, err)
+	}
+	if _, err := c.Refresh(context.Background()); !errors.Is(err, fail) {
+		t.Errorf("Refresh err = %v, want the load error", err)
+	}
+}
+
+func TestCache_StaleIfErrorExpires(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", "0s")
+	t.Setenv("GAMEHUB_LIVE_STALE_IF_ERROR", "0s")
+	fail := errors.New("boom")
+	var failing atomic.Bool
+	c := NewCache(time.Millisecond, func(ctx context.Context) (LiveContext, error) {
+		if failing.Load() {
+			return LiveContext{}, fail
+		}
+		return LiveContext{}, nil
+	})
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	failing.Store(true)
+	if _, err := c.Get(context.Background()); !errors.Is(err, fail) {
+		t.Errorf("err = %v, want the load error past the stale-if-error window", err)
+	}
+}
+
+func TestCache_ReadsDoNotWaitForLoad(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var loads atomic.Int32
+	c := NewCache(time.Hour, func(ctx context.Context) (LiveContext, error) {
+		if loads.Add(1) > 1 {
+			<-release
+		}
+		return LiveContext{SeriesIDs: []int{1}}, nil
+	})
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _, _ = c.Refresh(context.Background()) }()
+	for c.load.Load() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if lc, err := c.Get(ctx); err != nil || !lc.HasSeries(1) {
+		t.Errorf("Get during a load = %+v, %v; want the cached entry", lc, err)
+	}
+}
+
+// benchCache returns a cache with a fresh entry and a slow loader, and starts
+// reloading it back to back until the benchmark ends.
+func benchCache(b *testing.B, ttl time.Duration) *Cache {
+	b.Helper()
+	b.Setenv("GAMEHUB_LIVE_STALE_WHILE_REVALIDATE", "1h")
+	c := NewCache(ttl, func(ctx context.Context) (LiveContext, error) {
+		time.Sleep(time.Millisecond) // a series -> rosters round trip, shortened
+		return LiveContext{SeriesIDs: []int{1, 2, 3}}, nil
+	})
+	if _, err := c.Get(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for ctx.Err() == nil {
+			_, _ = c.Refresh(ctx)
+		}
+	})
+	b.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	return c
+}
+
+// benchGet runs Get from parallel readers and reports p50/p99/max latency.
+func benchGet(b *testing.B, c *Cache) {
+	var mu sync.Mutex
+	var all []time.Duration
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		lat := make([]time.Duration, 0, 1024)
+		for pb.Next() {
+			start := time.Now()
+			if _, err := c.Get(context.Background()); err != nil {
+				b.Error(err)
+				return
+			}
+			lat = append(lat, time.Since(start))
+		}
+		mu.Lock()
+		all = append(all, lat...)
+		mu.Unlock()
+	})
+	b.StopTimer()
+	if len(all) == 0 {
+		return
+	}
+	slices.Sort(all)
+	b.ReportMetric(float64(all[len(all)/2].Nanoseconds()), "p50-ns")
+	b.ReportMetric(float64(all[len(all)*99/100].Nanoseconds()), "p99-ns")
+	b.ReportMetric(float64(all[len(all)-1].Nanoseconds()), "max-ns")
+}
+
+// BenchmarkCache_GetDuringReload reads a fresh entry while loads run back to back.
+func BenchmarkCache_GetDuringReload(b *testing.B) {
+	benchGet(b, benchCache(b, time.Hour))
+}
+
+// BenchmarkCache_GetStaleDuringReload reads an expired entry within the
+// stale-while-revalidate window, so every read checks for a revalidation.
+func BenchmarkCache_GetStaleDuringReload(b *testing.B) {
+	benchGet(b, benchCache(b, time.Nanosecond))
+}
@@ -0,0 +1,206 @@
+package live
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// EventType is the kind of change between two live context snapshots.
+type EventType string
+
+const (
+	SeriesWentLive EventType = "series.live"
+	SeriesEnded    EventType = "series.ended"
+	TeamJoined     EventType = "team.joined"
+	TeamLeft       EventType = "team.left"
+	PlayerJoined   EventType = "player.joined"
+	PlayerLeft     EventType = "player.left"
+)
+
+// Event is one change to the live context. Seq increases by one per event
+// across the life of the process.
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Type EventType `json:"type"`
+	ID   int       `json:"id"`
+	Time time.Time `json:"time"` // when the change was detected
+}
+
+// Diff returns the changes from prev to next without Seq or Time: series that
+// went live or ended, then teams and players that joined or left, each in ID
+// order.
+func Diff(prev, next LiveContext) []Event {
+	var events []Event
+	events = diffIDs(events, prev.SeriesIDs, next.SeriesIDs, SeriesWentLive, SeriesEnded)
+	events = diffIDs(events, prev.TeamIDs, next.TeamIDs, TeamJoined, TeamLeft)
+	events = diffIDs(events, prev.PlayerIDs, next.PlayerIDs, PlayerJoined, PlayerLeft)
+	return events
+}
+
+func diffIDs(events []Event, prev, next []int, added, removed EventType) []Event {
+	in := func(ids []int) map[int]bool {
+		m := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	was, is := in(prev), in(next)
+	var up, down []int
+	for id := range is {
+		if !was[id] {
+			up = append(up, id)
+		}
+	}
+	for id := range was {
+		if !is[id] {
+			down = append(down, id)
+		}
+	}
+	slices.Sort(up)
+	slices.Sort(down)
+	for _, id := range up {
+		events = append(events, Event{Type: added, ID: id})
+	}
+	for _, id := range down {
+		events = append(events, Event{Type: removed, ID: id})
+	}
+	return events
+}
+
+// Feed publishes the changes between successive live contexts. The first
+// snapshot is the baseline and produces no events. Recent events are kept
+// (GAMEHUB_LIVE_EVENT_HISTORY) so a subscriber can resume after a gap.
+type Feed struct {
+	mu      sync.Mutex
+	last    *LiveContext
+	seq     uint64
+	history []Event // oldest first, at most size
+	size    int
+	subs    map[*Subscription]struct{}
+}
+
+// Subscription receives events from a Feed. C is closed when the
+// subscription is cancelled or dropped.
+type Subscription struct {
+	C <-chan Event
+
+	c       chan Event
+	feed    *Feed
+	dropped bool // guarded by feed.mu
+}
+
+// NewFeed returns an empty feed.
+func NewFeed() *Feed {
+	return &Feed{size: config.LiveEventHistory(), subs: make(map[*Subscription]struct{})}
+}
+
+// Update diffs lc against the previous snapshot and publishes the changes.
+func (f *Feed) Update(lc LiveContext) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prev := f.last
+	f.last = &lc
+	if prev == nil {
+		return
+	}
+	now := time.Now()
+	for _, e := range Diff(*prev, lc) {
+		f.seq++
+		e.Seq, e.Time = f.seq, now
+		f.history = append(f.history, e)
+		if len(f.history) > f.size {
+			f.history = slices.Delete(f.history, 0, len(f.history)-f.size)
+		}
+		metrics.LiveEvents.Add(1)
+		for s := range f.subs {
+			select {
+			case s.c <- e:
+			default:
+				// Never block a load on a slow subscriber: drop it.
+				s.dropped = true
+				f.removeLocked(s)
+				metrics.LiveSubscribersDropped.Add(1)
+			}
+		}
+	}
+}
+
+// Current returns the last snapshot and the sequence number of the last event
+// published up to it. ok is false before the first snapshot.
+func (f *Feed) Current() (lc LiveContext, seq uint64, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.last == nil {
+		return LiveContext{}, f.seq, false
+	}
+	return *f.last, f.seq, true
+}
+
+// Seq returns the sequence number of the last published event (0 if none).
+func (f *Feed) Seq() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seq
+}
+
+// Since returns the events after seq. ok is false if some of them are no
+// longer kept, in which case the caller must start over from a snapshot.
+func (f *Feed) Since(seq uint64) (events []Event, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sinceLocked(seq)
+}
+
+func (f *Feed) sinceLocked(seq uint64) ([]Event, bool) {
+	if seq >= f.seq {
+		return nil, seq == f.seq
+	}
+	if len(f.history) == 0 || f.history[0].Seq > seq+1 {
+		return nil, false
+	}
+	i := int(seq + 1 - f.history[0].Seq)
+	return slices.Clone(f.history[i:]), true
+}
+
+// Subscribe returns a subscription for events after seq, with room for buffer
+// events; a subscriber that falls further behind is dropped. The events after
+// seq still kept are delivered first; ok is false if some were lost (or seq is
+// ahead of the feed), and the subscription then starts from the next event.
+func (f *Feed) Subscribe(seq uint64, buffer int) (s *Subscription, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	missed, ok := f.sinceLocked(seq)
+	c := make(chan Event, max(buffer, len(missed)))
+	for _, e := range missed {
+		c <- e
+	}
+	s = &Subscription{C: c, c: c, feed: f}
+	f.subs[s] = struct{}{}
+	return s, ok
+}
+
+// Cancel stops the subscription and closes C. It is safe to call more than once.
+func (s *Subscription) Cancel() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.removeLocked(s)
+}
+
+// Dropped reports whether the subscription was dropped for falling behind.
+func (s *Subscription) Dropped() bool {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.dropped
+}
+
+func (f *Feed) removeLocked(s *Subscription) {
+	if _, ok := f.subs[s]; ok {
+		delete(f.subs, s)
+		close(s.c)
+	}
+}
@@ -0,0 +1,155 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/atlas/atlastest"
+)
+
+func eventsString(events []Event) string {
+	s := ""
+	for _, e := range events {
+		if e.Seq != 0 {
+			s += fmt.Sprintf("%d:", e.Seq)
+		}
+		s += fmt.Sprintf("%s/%d ", e.Type, e.ID)
+	}
+	return s
+}
+
+func TestDiff(t *testing.T) {
+	prev := LiveContext{SeriesIDs: []int{1, 2}, TeamIDs: []int{10, 11, 12}, PlayerIDs: []int{100}}
+	next := LiveContext{SeriesIDs: []int{3, 2}, TeamIDs: []int{12, 11, 13}, PlayerIDs: []int{100}}
+	got := eventsString(Diff(prev, next))
+	want := "series.live/3 series.ended/1 team.joined/13 team.left/10 "
+	if got != want {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+	if events := Diff(next, next); len(events) != 0 {
+		t.Errorf("Diff of equal snapshots = %v, want none", events)
+	}
+}
+
+func TestFeed_SequencesEventsAfterBaseline(t *testing.T) {
+	f := NewFeed()
+	f.Update(LiveContext{SeriesIDs: []int{1}})
+	if f.Seq() != 0 {
+		t.Fatalf("baseline published %d events", f.Seq())
+	}
+	f.Update(LiveContext{SeriesIDs: []int{1, 2}})
+	f.Update(LiveContext{SeriesIDs: []int{3}})
+
+	events, ok := f.Since(0)
+	if !ok {
+		t.Fatal("Since(0) not ok")
+	}
+	if got, want := eventsString(events), "1:series.live/2 2:series.live/3 3:series.ended/1 4:series.ended/2 "; got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+	if events, ok := f.Since(3); !ok || eventsString(events) != "4:series.ended/2 " {
+		t.Errorf("Since(3) = %q, %v", eventsString(events), ok)
+	}
+	if events, ok := f.Since(4); !ok || len(events) != 0 {
+		t.Errorf("Since(4) = %v, %v; want none, ok", events, ok)
+	}
+	if _, ok := f.Since(9); ok {
+		t.Error("Since past the feed: ok, want not ok")
+	}
+	if lc, seq, ok := f.Current(); !ok || seq != 4 || !lc.HasSeries(3) {
+		t.Errorf("Current = %v, %d, %v", lc, seq, ok)
+	}
+}
+
+func TestFeed_HistoryGap(t *testing.T) {
+	t.Setenv("GAMEHUB_LIVE_EVENT_HISTORY", "2")
+	f := NewFeed()
+	f.Update(LiveContext{})
+	f.Update(LiveContext{SeriesIDs: []int{1, 2, 3}})
+	if _, ok := f.Since(0); ok {
+		t.Error("Since(0) ok after events were evicted")
+	}
+	if events, ok := f.Since(1); !ok || eventsString(events) != "2:series.live/2 3:series.live/3 " {
+		t.Errorf("Since(1) = %q, %v", eventsString(events), ok)
+	}
+}
+
+func TestFeed_SubscribeResumesAndDropsSlowConsumers(t *testing.T) {
+	f := NewFeed()
+	f.Update(LiveContext{})
+	f.Update(LiveContext{SeriesIDs: []int{1}})
+
+	resumed, ok := f.Subscribe(0, 1)
+	if !ok {
+		t.Fatal("Subscribe(0) not ok")
+	}
+	if e := <-resumed.C; e.Seq != 1 || e.Type != SeriesWentLive {
+		t.Errorf("replayed %+v, want seq 1 series.live", e)
+	}
+	slow, _ := f.Subscribe(f.Seq(), 1)
+
+	f.Update(LiveContext{SeriesIDs: []int{1, 2}})
+	if e := <-resumed.C; e.Seq != 2 {
+		t.Errorf("live event %+v, want seq 2", e)
+	}
+	f.Update(LiveContext{SeriesIDs: []int{1, 2, 3}})
+	// slow never read: it holds seq 2, seq 3 does not fit.
+	if !slow.Dropped() {
+		t.Error("slow subscriber not dropped")
+	}
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("slow subscriber drained %d events, want 1", n)
+	}
+
+	resumed.Cancel()
+	resumed.Cancel()
+	if _, open := <-resumed.C; open {
+		// seq 3 was buffered before Cancel; the channel closes after it.
+		if _, open := <-resumed.C; open {
+			t.Error("C not closed after Cancel")
+		}
+	}
+	if resumed.Dropped() {
+		t.Error("cancelled subscriber reported as dropped")
+	}
+}
+
+func TestService_PublishesLiveChanges(t *testing.T) {
+	srv := atlastest.NewServer()
+	defer srv.Close()
+	srv.AddSeries(atlas.Series{ID: 1, Lifecycle: "live", Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 10}}}})
+	srv.AddRosters(
+		atlas.Roster{ID: 10, Team: atlas.Ref{ID: 100}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 1}}}},
+		atlas.Roster{ID: 11, Team: atlas.Ref{ID: 101}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 2}}}},
+	)
+	svc := NewService(atlas.NewClientWithURL("test-secret", srv.URL), time.Minute)
+	if _, err := svc.GetLiveContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sub, _ := svc.Events().Subscribe(svc.Events().Seq(), 16)
+	defer sub.Cancel()
+
+	srv.Reset("/series")
+	srv.AddSeries(
+		atlas.Series{ID: 1, Lifecycle: "over", Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 10}}}},
+		atlas.Series{ID: 2, Lifecycle: "live", Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 11}}}},
+	)
+	if _, err := svc.cache.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	for range 6 {
+		events = append(events, <-sub.C)
+	}
+	want := "1:series.live/2 2:series.ended/1 3:team.joined/101 4:team.left/100 5:player.joined/2 6:player.left/1 "
+	if got := eventsString(events); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
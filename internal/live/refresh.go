@@ -0,0 +1,131 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/config"
+	"github.com/aaron/gamehub/internal/metrics"
+)
+
+// RefreshOutcome reports one background refresh of the live context.
+type RefreshOutcome struct {
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+	Failures int           // consecutive failed refreshes, including this one
+	Next     time.Duration // wait before the next refresh
+}
+
+// RunRefresher reloads the live context in the background until ctx is done,
+// so readers are served from the cache instead of waiting for a load. It
+// refreshes once at start and then shortly before each entry expires
+// (GAMEHUB_LIVE_REFRESH_AHEAD). After a failure it retries with exponential
+// backoff up to GAMEHUB_LIVE_REFRESH_MAX_INTERVAL, and it never starts a
+// refresh while Atlas has every key backing off. report, if not nil, is
+// called after each refresh.
+func (s *Service) RunRefresher(ctx context.Context, report func(RefreshOutcome)) {
+	r := &refresher{
+		cache:       s.cache,
+		interval:    refreshInterval(s.cache.ttl, config.LiveRefreshAhead()),
+		maxInterval: config.LiveRefreshMaxInterval(),
+		backoff:     s.client.BackoffRemaining,
+		report:      report,
+	}
+	r.run(warm(ctx))
+}
+
+// refreshInterval returns how often to refresh an entry that lives ttl: ahead
+// before it expires, or halfway through if ahead is not shorter than ttl.
+func refreshInterval(ttl, ahead time.Duration) time.Duration {
+	if ahead <= 0 || ahead >= ttl {
+		return max(ttl/2, time.Millisecond)
+	}
+	return ttl - ahead
+}
+
+type refresher struct {
+	cache       *Cache
+	interval    time.Duration
+	maxInterval time.Duration
+	backoff     func() time.Duration // time until Atlas may be called again
+	report      func(RefreshOutcome)
+}
+
+func (r *refresher) run(ctx context.Context) {
+	failures := 0
+	for {
+		// Wait out an Atlas backoff instead of queueing behind it.
+		if wait := r.backoff(); wait > 0 {
+			if !sleep(ctx, wait) {
+				return
+			}
+		}
+		start := time.Now()
+		_, err := r.cache.Refresh(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		out := RefreshOutcome{
+			Start:    start,
+			Duration: time.Since(start),
+			Err:      err,
+			Failures: failures,
+			Next:     r.next(err, failures),
+		}
+		r.record(out)
+		if !sleep(ctx, out.Next) {
+			return
+		}
+	}
+}
+
+// next returns the wait before the next refresh. Failures back off
+// exponentially from the normal interval; an Atlas backoff or Retry-After
+// pushes the next refresh past it.
+func (r *refresher) next(err error, failures int) time.Duration {
+	wait := r.interval
+	if err != nil {
+		for i := 1; i < failures && wait < r.maxInterval; i++ {
+			wait *= 2
+		}
+		wait = min(wait, r.maxInterval)
+		var rlErr *atlas.ErrRateLimited
+		if errors.As(err, &rlErr) {
+			wait = max(wait, time.Duration(rlErr.RetryAfterMs)*time.Millisecond)
+		}
+	}
+	return max(wait, r.backoff())
+}
+
+func (r *refresher) record(out RefreshOutcome) {
+	metrics.LiveRefreshes.Add(1)
+	metrics.LiveRefreshLastMs.Store(uint64(out.Duration.Milliseconds()))
+	if out.Err != nil {
+		metrics.LiveRefreshFailures.Add(1)
+		log.Printf("live: refresh failed (%d in a row, next in %v): %v", out.Failures, out.Next, out.Err)
+	}
+	if r.report != nil {
+		r.report(out)
+	}
+}
+
+// sleep waits for d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
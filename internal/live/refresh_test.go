@@ -0,0 +1,126 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+)
+
+func TestRefreshInterval(t *testing.T) {
+	tests := []struct {
+		ttl, ahead, want time.Duration
+	}{
+		{10 * time.Second, 2 * time.Second, 8 * time.Second},
+		{10 * time.Second, 0, 5 * time.Second},
+		{10 * time.Second, 10 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := refreshInterval(tt.ttl, tt.ahead); got != tt.want {
+			t.Errorf("refreshInterval(%v, %v) = %v, want %v", tt.ttl, tt.ahead, got, tt.want)
+		}
+	}
+}
+
+func TestRefresher_Next(t *testing.T) {
+	var backoff time.Duration
+	r := &refresher{
+		interval:    time.Second,
+		maxInterval: 5 * time.Second,
+		backoff:     func() time.Duration { return backoff },
+	}
+	fail := errors.New("boom")
+	if got := r.next(nil, 0); got != time.Second {
+		t.Errorf("success: next = %v, want 1s", got)
+	}
+	if got := r.next(fail, 1); got != time.Second {
+		t.Errorf("1 failure: next = %v, want 1s", got)
+	}
+	if got := r.next(fail, 3); got != 4*time.Second {
+		t.Errorf("3 failures: next = %v, want 4s", got)
+	}
+	if got := r.next(fail, 10); got != 5*time.Second {
+		t.Errorf("10 failures: next = %v, want capped 5s", got)
+	}
+	if got := r.next(&atlas.ErrRateLimited{RetryAfterMs: 3000}, 1); got != 3*time.Second {
+		t.Errorf("rate limited: next = %v, want Retry-After 3s", got)
+	}
+	backoff = 7 * time.Second
+	if got := r.next(nil, 0); got != 7*time.Second {
+		t.Errorf("backing off: next = %v, want 7s", got)
+	}
+}
+
+func TestRefresher_KeepsCacheWarm(t *testing.T) {
+	var loads atomic.Int32
+	c := NewCache(50*time.Millisecond, func(ctx context.Context) (LiveContext, error) {
+		n := loads.Add(1)
+		if n == 2 {
+			return LiveContext{}, errors.New("boom")
+		}
+		return LiveContext{SeriesIDs: []int{int(n)}}, nil
+	})
+	outcomes := make(chan RefreshOutcome, 16)
+	r := &refresher{
+		cache:       c,
+		interval:    20 * time.Millisecond,
+		maxInterval: time.Second,
+		backoff:     func() time.Duration { return 0 },
+		report:      func(o RefreshOutcome) { outcomes <- o },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.run(ctx)
+		close(done)
+	}()
+
+	for i, wantErr := range []bool{false, true, false} {
+		o := <-outcomes
+		if (o.Err != nil) != wantErr {
+			t.Errorf("refresh %d: err = %v, want error %v", i+1, o.Err, wantErr)
+		}
+		if wantErr && o.Failures != 1 {
+			t.Errorf("refresh %d: failures = %d, want 1", i+1, o.Failures)
+		}
+	}
+	// Readers are served by the refresher's loads, not their own.
+	before := loads.Load()
+	lc, err := c.Get(context.Background())
+	if err != nil || len(lc.SeriesIDs) == 0 {
+		t.Fatalf("Get = %v, %v", lc, err)
+	}
+	if loads.Load() != before {
+		t.Error("Get loaded while the refresher kept the cache warm")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("refresher did not stop on cancel")
+	}
+}
+
+func TestRefresher_WaitsOutBackoff(t *testing.T) {
+	var loads atomic.Int32
+	c := NewCache(time.Minute, func(ctx context.Context) (LiveContext, error) {
+		loads.Add(1)
+		return LiveContext{}, nil
+	})
+	r := &refresher{
+		cache:       c,
+		interval:    time.Minute,
+		maxInterval: time.Minute,
+		backoff:     func() time.Duration { return time.Hour },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	r.run(ctx)
+	if n := loads.Load(); n != 0 {
+		t.Errorf("loads = %d during backoff, want 0", n)
+	}
+}
@@ -2,7 +2,7 @@ package live
 
 import (
 	"context"
-	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/aaron/gamehub/internal/atlas"
@@ -12,66 +12,96 @@ import (
 type Service struct {
 	client *atlas.Client
 	cache  *Cache
+	feed   *Feed
 }
 
-// NewService creates a live service with a TTL cache.
+// NewService creates a live service with a TTL cache. Every live context it
+// loads is diffed against the previous one and the changes published on Events.
+// A cache miss loads with the priority of the caller waiting for it; only
+// revalidations and the refresher, which nobody waits for, load as
+// atlas.PriorityWarm.
 func NewService(client *atlas.Client, ttl time.Duration) *Service {
-	s := &Service{client: client}
-	s.cache = NewCache(ttl, func() (LiveContext, error) {
-		return s.loadLiveContext(context.Background())
+	s := &Service{client: client, feed: NewFeed()}
+	s.cache = NewCache(ttl, func(ctx context.Context) (LiveContext, error) {
+		return s.loadLiveContext(atlas.WithRetryPolicy(ctx, loadRetryPolicy()))
 	})
+	s.cache.onStore = s.feed.Update
+	s.cache.background = warm
 	return s
 }
 
+// warm marks ctx for loads nobody is waiting on.
+func warm(ctx context.Context) context.Context {
+	return atlas.WithPriority(ctx, atlas.PriorityWarm)
+}
+
+// loadRetryPolicy is used for live context loads. A load is shared by every
+// request waiting on the cache, so it is more patient than the per-request default.
+func loadRetryPolicy() atlas.RetryPolicy {
+	p := atlas.DefaultRetryPolicy()
+	p.MaxAttempts *= 2
+	p.Budget *= 2
+	return p
+}
+
 // loadLiveContext performs the full API flow: series -> roster IDs -> rosters -> team/player IDs.
+// Series and tournament IDs come straight from the live series.
 func (s *Service) loadLiveContext(ctx context.Context) (LiveContext, error) {
-	seriesBody, _, err := s.client.GetSeriesAll(ctx, map[string]string{"filter": "lifecycle=live"})
+	series, info, err := s.client.SeriesAll(ctx, atlas.NewQuery().Eq("lifecycle", "live").Params())
 	if err != nil {
 		return LiveContext{}, err
 	}
-	rosterIDs := extractRosterIDsFromSeries(seriesBody)
+	seriesIDs, tournamentIDs := extractSeriesAndTournamentIDs(series)
+	rosterIDs := extractRosterIDsFromSeries(series)
 	if len(rosterIDs) == 0 {
-		return LiveContext{TeamIDs: []int{}, PlayerIDs: []int{}}, nil
+		return LiveContext{SeriesIDs: seriesIDs, TournamentIDs: tournamentIDs, TeamIDs: []int{}, PlayerIDs: []int{},
+			PossiblyInconsistent: info.PossiblyInconsistent()}, nil
 	}
 	// Server-side filter: Atlas API returns only these rosters (Multiple Rosters by id).
-	rostersBody, _, err := s.client.GetRostersAll(ctx, map[string]string{
-		"filter": atlas.FilterIDIn(rosterIDs),
-	})
+	rosters, err := s.client.RostersByIDs(ctx, rosterIDs, nil)
 	if err != nil {
 		return LiveContext{}, err
 	}
-	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(rostersBody)
-	return LiveContext{TeamIDs: teamIDs, PlayerIDs: playerIDs}, nil
+	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(rosters)
+	return LiveContext{SeriesIDs: seriesIDs, TournamentIDs: tournamentIDs, TeamIDs: teamIDs, PlayerIDs: playerIDs,
+		PossiblyInconsistent: info.PossiblyInconsistent()}, nil
 }
 
-// GetLiveContext returns the cached or freshly loaded live context.
+// GetLiveContext returns the cached or freshly loaded live context. If ctx is
+// done before the load finishes, it returns ctx.Err(); the load is cancelled
+// once no caller is waiting for it.
 func (s *Service) GetLiveContext(ctx context.Context) (LiveContext, error) {
-	return s.cache.Get()
+	return s.cache.Get(ctx)
 }
 
-func extractRosterIDsFromSeries(data []byte) []int {
-	var series []map[string]interface{}
-	if err := json.Unmarshal(data, &series); err != nil {
-		return nil
+// Events returns the feed of changes between successive live contexts.
+func (s *Service) Events() *Feed {
+	return s.feed
+}
+
+func extractSeriesAndTournamentIDs(series []atlas.Series) (seriesIDs, tournamentIDs []int) {
+	seriesIDs = make([]int, 0, len(series))
+	tournaments := make(map[int]bool)
+	for _, s := range series {
+		seriesIDs = append(seriesIDs, s.ID)
+		if s.Tournament.ID != 0 {
+			tournaments[s.Tournament.ID] = true
+		}
+	}
+	tournamentIDs = make([]int, 0, len(tournaments))
+	for id := range tournaments {
+		tournamentIDs = append(tournamentIDs, id)
 	}
+	slices.Sort(tournamentIDs)
+	return seriesIDs, tournamentIDs
+}
+
+func extractRosterIDsFromSeries(series []atlas.Series) []int {
 	seen := make(map[int]bool)
 	for _, s := range series {
-		parts, ok := s["participants"].([]interface{})
-		if !ok {
-			continue
-		}
-		for _, p := range parts {
-			pm, ok := p.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			roster, ok := pm["roster"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			id, ok := numID(roster["id"])
-			if ok {
-				seen[id] = true
+		for _, p := range s.Participants {
+			if p.Roster.ID != 0 {
+				seen[p.Roster.ID] = true
 			}
 		}
 	}
@@ -79,33 +109,20 @@ func extractRosterIDsFromSeries(data []byte) []int {
 	for id := range seen {
 		out = append(out, id)
 	}
+	slices.Sort(out) // the roster filter, and so its cassette, must not depend on map order
 	return out
 }
 
-func extractTeamAndPlayerIDsFromRosters(data []byte) (teamIDs, playerIDs []int) {
-	var rosters []map[string]interface{}
-	if err := json.Unmarshal(data, &rosters); err != nil {
-		return nil, nil
-	}
+func extractTeamAndPlayerIDsFromRosters(rosters []atlas.Roster) (teamIDs, playerIDs []int) {
 	teams := make(map[int]bool)
 	players := make(map[int]bool)
 	for _, r := range rosters {
-		if team, ok := r["team"].(map[string]interface{}); ok {
-			if id, ok := numID(team["id"]); ok {
-				teams[id] = true
-			}
+		if r.Team.ID != 0 {
+			teams[r.Team.ID] = true
 		}
-		if lineUp, ok := r["line_up"].(map[string]interface{}); ok {
-			if plist, ok := lineUp["players"].([]interface{}); ok {
-				for _, p := range plist {
-					pm, ok := p.(map[string]interface{})
-					if !ok {
-						continue
-					}
-					if id, ok := numID(pm["id"]); ok {
-						players[id] = true
-					}
-				}
+		for _, p := range r.LineUp.Players {
+			if p.ID != 0 {
+				players[p.ID] = true
 			}
 		}
 	}
@@ -113,20 +130,11 @@ func extractTeamAndPlayerIDsFromRosters(data []byte) (teamIDs, playerIDs []int)
 	for id := range teams {
 		teamIDs = append(teamIDs, id)
 	}
+	slices.Sort(teamIDs)
 	playerIDs = make([]int, 0, len(players))
 	for id := range players {
 		playerIDs = append(playerIDs, id)
 	}
+	slices.Sort(playerIDs)
 	return teamIDs, playerIDs
 }
-
-func numID(v interface{}) (int, bool) {
-	switch x := v.(type) {
-	case float64:
-		return int(x), true
-	case int:
-		return x, true
-	default:
-		return 0, false
-	}
-}
@@ -1,16 +1,35 @@
 package live
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
 	"testing"
+	"time"
+
+	"github.com/aaron/gamehub/internal/atlas"
+	"github.com/aaron/gamehub/internal/atlas/atlastest"
 )
 
+func decode[T any](t *testing.T, data []byte) []T {
+	t.Helper()
+	var out []T
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
 func TestExtractRosterIDsFromSeries(t *testing.T) {
 	data := []byte(`[
 		{"participants":[{"roster":{"id":149001}},{"roster":{"id":139151}}]},
 		{"participants":[{"roster":{"id":148648}},{"roster":{"id":149000}}]},
 		{"participants":[]}
 	]`)
-	ids := extractRosterIDsFromSeries(data)
+	ids := extractRosterIDsFromSeries(decode[atlas.Series](t, data))
 	if len(ids) != 4 {
 		t.Errorf("want 4 unique roster IDs, got %d: %v", len(ids), ids)
 	}
@@ -27,7 +46,7 @@ func TestExtractRosterIDsFromSeries(t *testing.T) {
 
 func TestExtractRosterIDsFromSeries_Empty(t *testing.T) {
 	data := []byte(`[]`)
-	ids := extractRosterIDsFromSeries(data)
+	ids := extractRosterIDsFromSeries(decode[atlas.Series](t, data))
 	if len(ids) != 0 {
 		t.Errorf("want 0 roster IDs, got %v", ids)
 	}
@@ -38,7 +57,7 @@ func TestExtractTeamAndPlayerIDsFromRosters(t *testing.T) {
 		{"team":{"id":100},"line_up":{"players":[{"id":1},{"id":2}]}},
 		{"team":{"id":101},"line_up":{"players":[{"id":2},{"id":3}]}}
 	]`)
-	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(data)
+	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(decode[atlas.Roster](t, data))
 	if len(teamIDs) != 2 {
 		t.Errorf("want 2 team IDs, got %v", teamIDs)
 	}
@@ -49,7 +68,7 @@ func TestExtractTeamAndPlayerIDsFromRosters(t *testing.T) {
 
 func TestExtractTeamAndPlayerIDsFromRosters_Empty(t *testing.T) {
 	data := []byte(`[]`)
-	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(data)
+	teamIDs, playerIDs := extractTeamAndPlayerIDsFromRosters(decode[atlas.Roster](t, data))
 	if len(teamIDs) != 0 {
 		t.Errorf("want 0 team IDs, got %v", teamIDs)
 	}
@@ -57,3 +76,124 @@ func TestExtractTeamAndPlayerIDsFromRosters_Empty(t *testing.T) {
 		t.Errorf("want 0 player IDs, got %v", playerIDs)
 	}
 }
+
+func TestService_GetLiveContext_FakeAtlas(t *testing.T) {
+	srv := atlastest.NewServer()
+	defer srv.Close()
+	srv.AddSeries(
+		atlas.Series{ID: 1, Lifecycle: "live", Tournament: atlas.Ref{ID: 7}, Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 10}}, {Roster: atlas.Ref{ID: 11}}}},
+		atlas.Series{ID: 2, Lifecycle: "over", Tournament: atlas.Ref{ID: 8}, Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 12}}}},
+	)
+	srv.AddRosters(
+		atlas.Roster{ID: 10, Team: atlas.Ref{ID: 100}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 1}, {ID: 2}}}},
+		atlas.Roster{ID: 11, Team: atlas.Ref{ID: 101}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 3}}}},
+		atlas.Roster{ID: 12, Team: atlas.Ref{ID: 102}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 4}}}},
+	)
+
+	svc := NewService(atlas.NewClientWithURL("test-secret", srv.URL), time.Minute)
+	liveCtx, err := svc.GetLiveContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(liveCtx.TeamIDs)
+	sort.Ints(liveCtx.PlayerIDs)
+	if fmt.Sprint(liveCtx.TeamIDs) != "[100 101]" {
+		t.Errorf("TeamIDs = %v, want [100 101]", liveCtx.TeamIDs)
+	}
+	if fmt.Sprint(liveCtx.PlayerIDs) != "[1 2 3]" {
+		t.Errorf("PlayerIDs = %v, want [1 2 3]", liveCtx.PlayerIDs)
+	}
+	if fmt.Sprint(liveCtx.SeriesIDs) != "[1]" || fmt.Sprint(liveCtx.TournamentIDs) != "[7]" {
+		t.Errorf("SeriesIDs = %v, TournamentIDs = %v, want [1] and [7]", liveCtx.SeriesIDs, liveCtx.TournamentIDs)
+	}
+	if !liveCtx.HasSeries(1) || liveCtx.HasSeries(2) {
+		t.Error("HasSeries: want live series 1 only")
+	}
+
+	// Served from cache: no further Atlas requests.
+	n := len(srv.Requests())
+	if _, err := svc.GetLiveContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(srv.Requests()); got != n {
+		t.Errorf("cached call made %d Atlas requests", got-n)
+	}
+}
+
+func TestService_ReplaysRecordedLoad(t *testing.T) {
+	srv := atlastest.NewServer()
+	defer srv.Close()
+	for i := 1; i <= 12; i++ {
+		srv.AddSeries(atlas.Series{ID: i, Lifecycle: "live", Tournament: atlas.Ref{ID: 70 + i%3},
+			Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 2 * i}}, {Roster: atlas.Ref{ID: 2*i + 1}}}})
+		srv.AddRosters(
+			atlas.Roster{ID: 2 * i, Team: atlas.Ref{ID: 200 + 2*i}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 4 * i}}}},
+			atlas.Roster{ID: 2*i + 1, Team: atlas.Ref{ID: 201 + 2*i}, LineUp: atlas.LineUp{Players: []atlas.Ref{{ID: 4*i + 1}}}},
+		)
+	}
+
+	dir := t.TempDir()
+	recording := NewService(atlas.NewClientWithURL("test-secret", srv.URL, atlas.WithTransport(atlas.NewRecorder(dir, nil))), time.Minute)
+	want, err := recording.GetLiveContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The roster filter is built from a set; replay only finds its cassette if
+	// every load asks for the IDs in the same order.
+	for range 5 {
+		replaying := NewService(atlas.NewClientWithURL("test-secret", "http://atlas.invalid", atlas.WithTransport(atlas.NewReplayer(dir))), time.Minute)
+		ctx := atlas.WithRetryPolicy(context.Background(), atlas.NoRetry)
+		got, err := replaying.loadLiveContext(ctx)
+		if err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		if fmt.Sprint(got.TeamIDs, got.PlayerIDs) != fmt.Sprint(want.TeamIDs, want.PlayerIDs) {
+			t.Fatalf("replayed teams/players %v %v, recorded %v %v", got.TeamIDs, got.PlayerIDs, want.TeamIDs, want.PlayerIDs)
+		}
+	}
+}
+
+func TestService_ColdMissIsNotShedWhenBucketIsDrained(t *testing.T) {
+	srv := atlastest.NewServer()
+	defer srv.Close()
+	srv.AddSeries(atlas.Series{ID: 1, Lifecycle: "live", Participants: []atlas.Participant{{Roster: atlas.Ref{ID: 10}}}})
+	srv.AddRosters(atlas.Roster{ID: 10, Team: atlas.Ref{ID: 100}})
+	srv.SetRateLimit(atlas.RateLimit{Limit: 10, Burst: 10, Remaining: 0})
+
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	// An interactive call leaves the bucket empty: the budget is 0, which sheds warm loads.
+	if _, _, err := client.Get(context.Background(), "/series"); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewService(client, time.Minute)
+	lc, err := svc.GetLiveContext(context.Background())
+	if err != nil {
+		t.Fatalf("cold miss with a drained bucket: %v, want it to wait for tokens", err)
+	}
+	if fmt.Sprint(lc.TeamIDs) != "[100]" {
+		t.Errorf("TeamIDs = %v, want [100]", lc.TeamIDs)
+	}
+}
+
+func TestService_FlagsPossiblyInconsistentSeries(t *testing.T) {
+	t.Setenv("GAMEHUB_PAGE_SIZE", "2")
+	// Series 2 is served again on the second page: a series was inserted
+	// before the offset while paginating.
+	pages := map[string]string{"0": `[{"id":1},{"id":2}]`, "2": `[{"id":2}]`}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(pages[r.URL.Query().Get("skip")]))
+	}))
+	defer srv.Close()
+
+	client := atlas.NewClientWithURL("test-secret", srv.URL)
+	client.SetPageConcurrency(1)
+	lc, err := NewService(client, time.Minute).GetLiveContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(lc.SeriesIDs) != "[1 2]" || !lc.PossiblyInconsistent {
+		t.Errorf("SeriesIDs = %v, PossiblyInconsistent = %v; want [1 2], true", lc.SeriesIDs, lc.PossiblyInconsistent)
+	}
+}
@@ -13,14 +13,90 @@ import (
 var monitorHTML []byte
 
 var (
-	RequestsTotal         atomic.Uint64
-	RequestsOK            atomic.Uint64
-	Inbound429            atomic.Uint64
-	Atlas429              atomic.Uint64
-	LastInboundRetryAfter atomic.Uint64 // seconds we sent on our 429
-	LastAtlasRetryAfter   atomic.Uint64 // ms Atlas told us to wait
+	RequestsTotal          atomic.Uint64
+	RequestsOK             atomic.Uint64
+	Inbound429             atomic.Uint64
+	Atlas429               atomic.Uint64
+	LastInboundRetryAfter  atomic.Uint64 // seconds we sent on our 429
+	LastAtlasRetryAfter    atomic.Uint64 // ms Atlas told us to wait
+	AtlasPaced             atomic.Uint64 // outbound requests delayed by our token bucket
+	AtlasRetries           atomic.Uint64 // outbound attempts retried after a transient failure
+	AtlasCoalesced         atomic.Uint64 // outbound calls served by an identical in-flight request
+	AtlasNotModified       atomic.Uint64 // outbound calls answered 304 and served from the stored body
+	LastAtlasRemaining     atomic.Uint64 // X-RateLimit-Remaining summed over usable API keys
+	AtlasBreakerRejected   atomic.Uint64 // outbound calls failed fast by the circuit breaker
+	AtlasBreakerOpens      atomic.Uint64 // times the circuit breaker opened
+	AtlasShed              atomic.Uint64 // outbound requests dropped by the priority scheduler
+	AtlasQueued            atomic.Int64  // outbound requests queued for pacing right now
+	LiveRefreshes          atomic.Uint64 // background live context refreshes
+	LiveRefreshFailures    atomic.Uint64 // background live context refreshes that failed
+	LiveRefreshLastMs      atomic.Uint64 // duration of the last background refresh
+	LiveStaleServed        atomic.Uint64 // live context reads served past the cache TTL
+	LiveEvents             atomic.Uint64 // live change events published
+	LiveSubscribersDropped atomic.Uint64 // live event subscribers dropped for falling behind
+	SSEClients             atomic.Int64  // clients connected to /events/live right now
+	WSConnections          atomic.Int64  // /ws connections open right now
+	WSConnectionsTotal     atomic.Uint64 // /ws connections accepted
+	WSSubscriptions        atomic.Int64  // topics subscribed over all /ws connections
+	WSMessagesIn           atomic.Uint64 // messages received from /ws clients
+	WSMessagesOut          atomic.Uint64 // messages sent to /ws clients
+	WSDropped              atomic.Uint64 // /ws connections closed for falling behind
+	atlasBreakerState      atomic.Value  // string: closed, open, half-open
 )
 
+// AtlasKeyStats holds usage of one Atlas API key.
+type AtlasKeyStats struct {
+	Requests  atomic.Uint64 // requests sent with the key
+	Limited   atomic.Uint64 // 429s received for the key
+	Remaining atomic.Int64  // X-RateLimit-Remaining from the key's last response
+	Disabled  atomic.Bool   // Atlas rejected the key (401, or 403 on several resources)
+}
+
+var (
+	atlasKeysMu sync.Mutex
+	atlasKeys   = map[string]*AtlasKeyStats{}
+)
+
+// AtlasKey returns the stats for the API key with the given label, creating
+// them on first use.
+func AtlasKey(label string) *AtlasKeyStats {
+	atlasKeysMu.Lock()
+	defer atlasKeysMu.Unlock()
+	s, ok := atlasKeys[label]
+	if !ok {
+		s = &AtlasKeyStats{}
+		atlasKeys[label] = s
+	}
+	return s
+}
+
+func atlasKeyStats() map[string]interface{} {
+	atlasKeysMu.Lock()
+	defer atlasKeysMu.Unlock()
+	out := make(map[string]interface{}, len(atlasKeys))
+	for label, s := range atlasKeys {
+		out[label] = map[string]interface{}{
+			"requests":  s.Requests.Load(),
+			"atlas_429": s.Limited.Load(),
+			"remaining": s.Remaining.Load(),
+			"disabled":  s.Disabled.Load(),
+		}
+	}
+	return out
+}
+
+// SetAtlasBreakerState records the Atlas circuit breaker state.
+func SetAtlasBreakerState(state string) {
+	atlasBreakerState.Store(state)
+}
+
+func loadAtlasBreakerState() string {
+	if s, ok := atlasBreakerState.Load().(string); ok {
+		return s
+	}
+	return "closed"
+}
+
 // RecordInboundRetryAfter records the Retry-After we sent (seconds).
 func RecordInboundRetryAfter(sec int) {
 	LastInboundRetryAfter.Store(uint64(sec))
@@ -31,6 +107,14 @@ func RecordAtlasRetryAfter(ms int) {
 	LastAtlasRetryAfter.Store(uint64(ms))
 }
 
+// RecordAtlasRemaining records the remaining Atlas quota over all usable API keys.
+func RecordAtlasRemaining(n int) {
+	if n < 0 {
+		n = 0
+	}
+	LastAtlasRemaining.Store(uint64(n))
+}
+
 const historySize = 120 // 2 min at 1 sample/sec
 
 type sample struct {
@@ -102,14 +186,38 @@ func Stats() map[string]interface{} {
 
 	return map[string]interface{}{
 		"total": map[string]interface{}{
-			"requests":              RequestsTotal.Load(),
-			"ok":                    RequestsOK.Load(),
-			"inbound_429":           Inbound429.Load(),
-			"atlas_429":             Atlas429.Load(),
-			"inbound_retry_after_s": LastInboundRetryAfter.Load(),
-			"atlas_retry_after_ms":  LastAtlasRetryAfter.Load(),
+			"requests":                 RequestsTotal.Load(),
+			"ok":                       RequestsOK.Load(),
+			"inbound_429":              Inbound429.Load(),
+			"atlas_429":                Atlas429.Load(),
+			"inbound_retry_after_s":    LastInboundRetryAfter.Load(),
+			"atlas_retry_after_ms":     LastAtlasRetryAfter.Load(),
+			"atlas_paced":              AtlasPaced.Load(),
+			"atlas_retries":            AtlasRetries.Load(),
+			"atlas_coalesced":          AtlasCoalesced.Load(),
+			"atlas_not_modified":       AtlasNotModified.Load(),
+			"atlas_breaker":            loadAtlasBreakerState(),
+			"atlas_breaker_opens":      AtlasBreakerOpens.Load(),
+			"atlas_breaker_reject":     AtlasBreakerRejected.Load(),
+			"atlas_remaining":          LastAtlasRemaining.Load(),
+			"atlas_shed":               AtlasShed.Load(),
+			"atlas_queued":             AtlasQueued.Load(),
+			"live_refreshes":           LiveRefreshes.Load(),
+			"live_refresh_failures":    LiveRefreshFailures.Load(),
+			"live_refresh_last_ms":     LiveRefreshLastMs.Load(),
+			"live_stale_served":        LiveStaleServed.Load(),
+			"live_events":              LiveEvents.Load(),
+			"live_subscribers_dropped": LiveSubscribersDropped.Load(),
+			"sse_clients":              SSEClients.Load(),
+			"ws_connections":           WSConnections.Load(),
+			"ws_connections_total":     WSConnectionsTotal.Load(),
+			"ws_subscriptions":         WSSubscriptions.Load(),
+			"ws_messages_in":           WSMessagesIn.Load(),
+			"ws_messages_out":          WSMessagesOut.Load(),
+			"ws_dropped":               WSDropped.Load(),
 		},
-		"history": samples,
+		"atlas_keys": atlasKeyStats(),
+		"history":    samples,
 	}
 }
 
@@ -141,6 +249,19 @@ func (r *responseRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer (deadlines,
+// hijacking) for streaming handlers.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush sends buffered data to the client, if the underlying writer supports it.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // paths excluded from main traffic metrics (monitoring endpoints)
 var excludedPaths = map[string]bool{"/stats": true, "/monitor": true}
 
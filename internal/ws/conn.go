@@ -0,0 +1,316 @@
+// Package ws is a minimal WebSocket (RFC 6455) implementation on the standard
+// library: the server handshake (Upgrade), a client (Dial, for tests and
+// tools), text and binary messages with fragmentation, ping/pong and the close
+// handshake. Extensions and subprotocols are not supported.
+//
+//	conn, err := ws.Upgrade(w, r)
+//	if err != nil {
+//		return // Upgrade has answered the request
+//	}
+//	defer conn.Close(ws.CloseNormal, "")
+//	for {
+//		typ, msg, err := conn.ReadMessage()
+//		...
+//	}
+package ws
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+const (
+	Text   MessageType = opText
+	Binary MessageType = opBinary
+)
+
+// Close codes (RFC 6455, section 7.4.1).
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseTooBig          = 1009
+	CloseTryAgainLater   = 1013
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+
+	maxControlPayload = 125
+	defaultReadLimit  = 64 << 10
+	defaultWriteWait  = 10 * time.Second
+)
+
+// ErrClosed is returned by writes after the connection was closed.
+var ErrClosed = errors.New("ws: connection closed")
+
+// CloseError is returned by ReadMessage when the peer closed the connection,
+// or when this side closed it for a protocol violation.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("ws: closed with code %d %s", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection. One goroutine may read while others write;
+// writes are serialized.
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // client frames are masked, server frames are not
+
+	readLimit   int64
+	readTimeout time.Duration // extended on every frame; 0 = none
+
+	wmu       sync.Mutex
+	writeWait time.Duration
+	closeSent bool
+}
+
+func newConn(c net.Conn, br *bufio.Reader, client bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(c)
+	}
+	return &Conn{conn: c, br: br, client: client, readLimit: defaultReadLimit, writeWait: defaultWriteWait}
+}
+
+// SetReadLimit sets the largest message ReadMessage accepts; a larger one
+// closes the connection with CloseTooBig.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readLimit = n
+}
+
+// SetReadTimeout makes ReadMessage fail if no frame, including a pong, arrives
+// within d. Call it before reading; pings sent every d/2 keep a live peer in.
+func (c *Conn) SetReadTimeout(d time.Duration) {
+	c.readTimeout = d
+}
+
+// RemoteAddr returns the peer address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next data message. Pings are answered and pongs
+// consumed while waiting. A close from the peer is answered and returned as
+// *CloseError.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var typ MessageType
+	var msg []byte
+	started := false
+	for {
+		if c.readTimeout > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ce := parseClose(payload)
+			code := ce.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			_ = c.Close(code, "")
+			return 0, nil, ce
+		case opText, opBinary:
+			if started {
+				return 0, nil, c.fail(protocolError("new message inside a fragmented one"))
+			}
+			typ, msg, started = MessageType(op), payload, true
+		case opContinuation:
+			if !started {
+				return 0, nil, c.fail(protocolError("continuation without a message"))
+			}
+			msg = append(msg, payload...)
+		default:
+			return 0, nil, c.fail(protocolError(fmt.Sprintf("unknown opcode %#x", op)))
+		}
+		if int64(len(msg)) > c.readLimit {
+			return 0, nil, c.fail(&CloseError{Code: CloseTooBig, Reason: "message too big"})
+		}
+		if fin {
+			if typ == Text && !utf8.Valid(msg) {
+				return 0, nil, c.fail(&CloseError{Code: CloseInvalidPayload, Reason: "invalid UTF-8"})
+			}
+			return typ, msg, nil
+		}
+	}
+}
+
+func protocolError(reason string) *CloseError {
+	return &CloseError{Code: CloseProtocolError, Reason: reason}
+}
+
+// fail closes the connection after a read error, telling the peer why if the
+// error is a protocol violation.
+func (c *Conn) fail(err error) error {
+	var ce *CloseError
+	if errors.As(err, &ce) {
+		_ = c.Close(ce.Code, ce.Reason)
+		return err
+	}
+	_ = c.conn.Close()
+	return err
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, protocolError("reserved bits set")
+	}
+	if masked := h[1]&0x80 != 0; masked == c.client {
+		return false, 0, nil, protocolError("bad masking")
+	}
+	n := uint64(h[1] & 0x7f)
+	switch n {
+	case 126:
+		var b [2]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err := io.ReadFull(c.br, b[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(b[:])
+	}
+	if op >= opClose && (!fin || n > maxControlPayload) {
+		return false, 0, nil, protocolError("bad control frame")
+	}
+	if n > uint64(c.readLimit) {
+		return false, 0, nil, &CloseError{Code: CloseTooBig, Reason: "message too big"}
+	}
+	var key [4]byte
+	if !c.client {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if !c.client {
+		maskBytes(key, payload)
+	}
+	return fin, op, payload, nil
+}
+
+func parseClose(p []byte) *CloseError {
+	if len(p) < 2 {
+		return &CloseError{Code: CloseNoStatus}
+	}
+	return &CloseError{Code: int(binary.BigEndian.Uint16(p)), Reason: string(p[2:])}
+}
+
+// WriteMessage sends one unfragmented data message.
+func (c *Conn) WriteMessage(typ MessageType, p []byte) error {
+	return c.writeFrame(byte(typ), p)
+}
+
+// Ping sends a ping; the peer's pong extends the read timeout.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// Close sends a close frame with code and reason, if none was sent yet, and
+// closes the connection.
+func (c *Conn) Close(code int, reason string) error {
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	p = append(p, reason[:min(len(reason), maxControlPayload-2)]...)
+	err := c.writeFrame(opClose, p)
+	if cerr := c.conn.Close(); err == nil {
+		err = cerr
+	}
+	if errors.Is(err, ErrClosed) || errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *Conn) writeFrame(op byte, p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+	buf := make([]byte, 0, 14+len(p))
+	buf = append(buf, 0x80|op)
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(p); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	start := len(buf)
+	if c.client {
+		var key [4]byte
+		_, _ = rand.Read(key[:])
+		buf = append(buf, key[:]...)
+		start = len(buf)
+		buf = append(buf, p...)
+		maskBytes(key, buf[start:])
+	} else {
+		buf = append(buf, p...)
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeWait))
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func maskBytes(key [4]byte, p []byte) {
+	for i := range p {
+		p[i] ^= key[i%4]
+	}
+}
@@ -0,0 +1,249 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3.
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("acceptKey = %q", got)
+	}
+}
+
+// echoServer echoes data messages and reports the error that ended each connection.
+func echoServer(t *testing.T, setup func(*Conn)) (string, <-chan error) {
+	t.Helper()
+	errs := make(chan error, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		if setup != nil {
+			setup(c)
+		}
+		for {
+			typ, msg, err := c.ReadMessage()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := c.WriteMessage(typ, msg); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), errs
+}
+
+func dial(t *testing.T, url string) *Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.conn.Close() })
+	return c
+}
+
+// writeRaw sends one client frame with the given FIN bit and opcode.
+func writeRaw(t *testing.T, c *Conn, fin bool, op byte, p []byte) {
+	t.Helper()
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	key := [4]byte{1, 2, 3, 4}
+	buf := append([]byte{b0, 0x80 | byte(len(p))}, key[:]...)
+	masked := append([]byte(nil), p...)
+	maskBytes(key, masked)
+	if _, err := c.conn.Write(append(buf, masked...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConn_EchoTextAndBinary(t *testing.T) {
+	url, _ := echoServer(t, nil)
+	c := dial(t, url)
+	for _, tt := range []struct {
+		typ MessageType
+		msg string
+	}{
+		{Text, "hello"},
+		{Binary, "\x00\x01"},
+		{Text, strings.Repeat("x", 300)},   // 16-bit length
+		{Text, strings.Repeat("y", 1<<16)}, // 64-bit length, at the default limit
+	} {
+		if err := c.WriteMessage(tt.typ, []byte(tt.msg)); err != nil {
+			t.Fatal(err)
+		}
+		typ, msg, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != tt.typ || string(msg) != tt.msg {
+			t.Errorf("echo of %d bytes: got type %d, %d bytes", len(tt.msg), typ, len(msg))
+		}
+	}
+}
+
+func TestConn_FragmentedMessageAndPing(t *testing.T) {
+	url, _ := echoServer(t, nil)
+	c := dial(t, url)
+	writeRaw(t, c, false, opText, []byte("hel"))
+	writeRaw(t, c, true, opPing, []byte("p")) // control frames may interleave
+	writeRaw(t, c, true, opContinuation, []byte("lo"))
+
+	// The pong arrives before the echo; ReadMessage consumes it.
+	typ, msg, err := c.ReadMessage()
+	if err != nil || typ != Text || string(msg) != "hello" {
+		t.Errorf("got %d %q %v, want text hello", typ, msg, err)
+	}
+}
+
+func TestConn_CloseHandshake(t *testing.T) {
+	url, errs := echoServer(t, nil)
+	c := dial(t, url)
+	if err := c.Close(CloseGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	var ce *CloseError
+	if err := <-errs; !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Reason != "bye" {
+		t.Errorf("server saw %v, want close 1001 bye", err)
+	}
+	if err := c.WriteMessage(Text, []byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("write after close: %v, want ErrClosed", err)
+	}
+}
+
+func TestConn_ProtocolViolations(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(t *testing.T, c *Conn)
+		code int
+	}{
+		{"unmasked frame", func(t *testing.T, c *Conn) {
+			_, _ = c.conn.Write([]byte{0x81, 0x01, 'x'})
+		}, CloseProtocolError},
+		{"orphan continuation", func(t *testing.T, c *Conn) {
+			writeRaw(t, c, true, opContinuation, []byte("x"))
+		}, CloseProtocolError},
+		{"invalid UTF-8", func(t *testing.T, c *Conn) {
+			writeRaw(t, c, true, opText, []byte{0xff, 0xfe})
+		}, CloseInvalidPayload},
+		{"too big", func(t *testing.T, c *Conn) {
+			writeRaw(t, c, true, opText, []byte(strings.Repeat("z", 20)))
+		}, CloseTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, errs := echoServer(t, func(c *Conn) { c.SetReadLimit(10) })
+			c := dial(t, url)
+			tt.send(t, c)
+			var ce *CloseError
+			if err := <-errs; !errors.As(err, &ce) || ce.Code != tt.code {
+				t.Errorf("server err = %v, want close %d", err, tt.code)
+			}
+			// The client is told why.
+			if _, _, err := c.ReadMessage(); !errors.As(err, &ce) || ce.Code != tt.code {
+				t.Errorf("client err = %v, want close %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestConn_ReadTimeout(t *testing.T) {
+	url, errs := echoServer(t, func(c *Conn) { c.SetReadTimeout(20 * time.Millisecond) })
+	dial(t, url)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("want timeout error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("idle connection not timed out")
+	}
+}
+
+func TestUpgrade_RejectsBadHandshakes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = Upgrade(w, r)
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name    string
+		version string
+		key     string
+		want    int
+	}{
+		{"plain request", "", "", http.StatusBadRequest},
+		{"old version", "8", "dGhlIHNhbXBsZSBub25jZQ==", http.StatusUpgradeRequired},
+		{"bad key", "13", "short", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if tt.version != "" {
+			req.Header.Set("Connection", "keep-alive, Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", tt.version)
+			req.Header.Set("Sec-WebSocket-Key", tt.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestUpgrade_ChecksOrigin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := Upgrade(w, r, "https://overlay.example.com"); err == nil {
+			_ = c.Close(CloseNormal, "")
+		}
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name   string
+		origin string
+		want   int
+	}{
+		{"no origin", "", http.StatusSwitchingProtocols},
+		{"same host", srv.URL, http.StatusSwitchingProtocols},
+		{"allowed", "https://Overlay.example.com", http.StatusSwitchingProtocols},
+		{"other site", "https://evil.example.com", http.StatusForbidden},
+		{"opaque", "null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}